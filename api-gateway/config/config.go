@@ -1,43 +1,47 @@
 package config
 
 import (
-	"log"
+	"errors"
 
-	"github.com/joho/godotenv"
-	"github.com/spf13/viper"
+	sharedconfig "github.com/MuxSphere/microkit/shared/config"
+	"go.uber.org/zap/zapcore"
 )
 
-type Config struct {
-	Port        string
-	ServiceAURL string
-	ServiceBURL string
-	RateLimit   int
-	JWTSecret   string
-	ConsulAddr  string
-}
+// InsecureJWTSecret is the placeholder JWT secret from .env.example. It is
+// only accepted in development.
+const InsecureJWTSecret = "your-secret-key"
 
-func Load() (*Config, error) {
-	// Loads environment variables from .env file if it exists
-	if err := godotenv.Load(); err != nil {
-		log.Printf("No .env file found: %v", err)
-	}
+// Development is the APP_ENV of local and docker-compose setups.
+const Development = "development"
 
-	viper.AutomaticEnv()
-
-	viper.SetDefault("PORT", "8000")
-	viper.SetDefault("SERVICE_A_URL", "http://service-a:8080")
-	viper.SetDefault("SERVICE_B_URL", "http://service-b:8080")
-	viper.SetDefault("RATE_LIMIT", 100)
-	viper.SetDefault("JWT_SECRET", "your-secret-key")
-	viper.SetDefault("CONSUL_ADDR", "consul:8500")
+type Config struct {
+	Env           string        `config:"app_env" default:"production" validate:"oneof=development test staging production"`
+	Port          int           `config:"port" default:"8000" validate:"min=1,max=65535"`
+	ServiceAURL   string        `config:"service_a_url" default:"http://service-a:8080"`
+	ServiceBURL   string        `config:"service_b_url" default:"http://service-b:8080"`
+	RateLimit     int           `config:"rate_limit" default:"100" validate:"min=1"`
+	JWTSecret     string        `config:"jwt_secret" validate:"required" secret:"true"`
+	ConsulAddr    string        `config:"consul_addr" default:"consul:8500"`
+	DiscoveryURL  string        `config:"discovery_url"`
+	LogLevel      zapcore.Level `config:"log_level" default:"info"`
+	LogAdminToken string        `config:"log_admin_token" secret:"true"`
+	LogDebugKey   string        `config:"log_debug_key" secret:"true"`
+	Routes        string        `config:"routes" default:"service-a=service-a,service-b=service-b"`
+}
 
+// Load reads the configuration through loader.
+func Load(loader *sharedconfig.Loader) (*Config, error) {
 	var cfg Config
-	cfg.Port = viper.GetString("PORT")
-	cfg.ServiceAURL = viper.GetString("SERVICE_A_URL")
-	cfg.ServiceBURL = viper.GetString("SERVICE_B_URL")
-	cfg.RateLimit = viper.GetInt("RATE_LIMIT")
-	cfg.JWTSecret = viper.GetString("JWT_SECRET")
-	cfg.ConsulAddr = viper.GetString("CONSUL_ADDR")
+	if err := loader.Load(&cfg); err != nil {
+		return nil, err
+	}
+	if cfg.JWTSecret == InsecureJWTSecret && cfg.Env != Development {
+		return nil, errors.New("JWT_SECRET: the placeholder secret is only allowed with APP_ENV=development")
+	}
 
+	// DISCOVERY_URL selects the registry backend and falls back to CONSUL_ADDR
+	if cfg.DiscoveryURL == "" {
+		cfg.DiscoveryURL = cfg.ConsulAddr
+	}
 	return &cfg, nil
 }
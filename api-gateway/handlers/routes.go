@@ -5,44 +5,111 @@ import (
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"strings"
+	"sync"
 
-	"github.com/MuxSphere/microkit/api-gateway/config"
 	"github.com/MuxSphere/microkit/shared/discovery"
+	apperrors "github.com/MuxSphere/microkit/shared/errors"
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRoutes(r *gin.Engine, cfg *config.Config) {
-	// Service discovery with Consul
-	sd, err := discovery.NewServiceDiscovery(cfg.ConsulAddr)
-	if err != nil {
-		panic(err)
+// VersionHeader pins a proxied request to instances registered with that
+// version.
+const VersionHeader = "X-Service-Version"
+
+// Routes maps the first path segment of a request to the service it is
+// proxied to. The table can be replaced while serving.
+type Routes struct {
+	mu       sync.RWMutex
+	services map[string]string
+}
+
+func NewRoutes(services map[string]string) *Routes {
+	rt := &Routes{}
+	rt.Set(services)
+	return rt
+}
+
+// ParseRoutes parses a table such as "service-a=service-a,orders=service-b".
+func ParseRoutes(s string) (map[string]string, error) {
+	services := make(map[string]string)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		prefix, service, ok := strings.Cut(entry, "=")
+		prefix, service = strings.Trim(strings.TrimSpace(prefix), "/"), strings.TrimSpace(service)
+		if !ok || prefix == "" || service == "" || strings.Contains(prefix, "/") {
+			return nil, fmt.Errorf("invalid route %q", entry)
+		}
+		services[prefix] = service
 	}
+	return services, nil
+}
+
+func (rt *Routes) Set(services map[string]string) {
+	table := make(map[string]string, len(services))
+	for prefix, service := range services {
+		table[prefix] = service
+	}
+
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.services = table
+}
+
+func (rt *Routes) Lookup(prefix string) (string, bool) {
+	rt.mu.RLock()
+	defer rt.mu.RUnlock()
+	service, ok := rt.services[prefix]
+	return service, ok
+}
 
+func SetupRoutes(r *gin.Engine, routes *Routes, resolver discovery.Resolver) {
 	// Health check route
 	r.GET("/health", healthCheck)
 
-	// Proxies for service-a and service-b
-	r.Any("/service-a/*path", createServiceProxy(sd, "service-a"))
-	r.Any("/service-b/*path", createServiceProxy(sd, "service-b"))
+	// Proxies for the services in the route table
+	proxy := createServiceProxy(routes, resolver)
+	r.Any("/:prefix", proxy)
+	r.Any("/:prefix/*path", proxy)
 }
 
 func healthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
 
-func createServiceProxy(sd *discovery.ServiceDiscovery, serviceName string) gin.HandlerFunc {
+func createServiceProxy(routes *Routes, resolver discovery.Resolver) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Discover the service through Consul
-		service, err := sd.DiscoverService(serviceName)
+		serviceName, ok := routes.Lookup(c.Param("prefix"))
+		if !ok {
+			apperrors.WriteProblem(c, apperrors.NotFound("No service is routed at this path"))
+			return
+		}
+
+		// Discover the service through the registry, pinned to a version
+		// when the client asks for one
+		var tags []string
+		if version := c.GetHeader(VersionHeader); version != "" {
+			tags = append(tags, discovery.VersionTag(version))
+		}
+		service, err := resolver.DiscoverService(serviceName, tags...)
 		if err != nil {
-			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Service unavailable"})
+			apperrors.WriteProblem(c, apperrors.Wrap(err, apperrors.CodeUnavailable, "Service unavailable"))
 			return
 		}
 
 		// Creates the proxy for the discovered service
-		targetURL := fmt.Sprintf("http://%s:%d", service.Address, service.Port)
-		url, _ := url.Parse(targetURL)
+		url, err := url.Parse(service.URL())
+		if err != nil {
+			apperrors.WriteProblem(c, apperrors.Wrap(err, apperrors.CodeBadGateway, "Invalid service address"))
+			return
+		}
 		proxy := httputil.NewSingleHostReverseProxy(url)
+		proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+			apperrors.WriteProblem(c, apperrors.Wrap(err, apperrors.CodeBadGateway, "Service did not respond"))
+		}
 		proxy.ServeHTTP(c.Writer, c.Request)
 	}
 }
@@ -1,43 +1,101 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"strconv"
 
 	"github.com/MuxSphere/microkit/api-gateway/config"
 	"github.com/MuxSphere/microkit/api-gateway/handlers"
 	"github.com/MuxSphere/microkit/api-gateway/middleware"
+	sharedconfig "github.com/MuxSphere/microkit/shared/config"
+	"github.com/MuxSphere/microkit/shared/discovery"
+	"github.com/MuxSphere/microkit/shared/dynconfig"
+	"github.com/MuxSphere/microkit/shared/logger"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
 func main() {
-	// Load configuration
-	cfg, err := config.Load()
+	// Load configuration from the environment, files and flags
+	loader := sharedconfig.New()
+	loader.Args = os.Args[1:]
+	cfg, err := config.Load(loader)
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	// Create a production logger
-	logger, err := zap.NewProduction()
+	// Create a production logger whose level follows LOG_LEVEL
+	level := zap.NewAtomicLevelAt(cfg.LogLevel)
+	l, err := logger.NewWithLevel(level)
 	if err != nil {
 		log.Fatalf("Failed to create logger: %v", err)
 	}
-	defer logger.Sync()
+	defer l.Sync()
+	l.Info("Loaded configuration", zap.Any("config", sharedconfig.Redacted(cfg)))
+
+	// Service discovery, falling back to the configured service URLs when
+	// DISCOVERY_URL is static://
+	sd, err := discovery.Open(cfg.DiscoveryURL, map[string][]string{
+		"service-a": {cfg.ServiceAURL},
+		"service-b": {cfg.ServiceBURL},
+	})
+	if err != nil {
+		l.Fatal("Failed to create service discovery client", zap.Error(err))
+	}
+
+	// Layer Consul KV under config/api-gateway/ over the environment
+	var kv dynconfig.KV
+	if consul, ok := sd.(*discovery.ServiceDiscovery); ok {
+		kv = consul.Client().KV()
+	}
+	dyn := dynconfig.New(kv, "config/api-gateway", l)
+	dyn.Viper = loader.Viper()
+	if err := dyn.Load(context.Background()); err != nil {
+		l.Warn("Failed to load configuration from Consul", zap.Error(err))
+	} else if cfg, err = config.Load(loader); err != nil {
+		l.Fatal("Failed to load configuration", zap.Error(err))
+	}
+
+	table, err := handlers.ParseRoutes(cfg.Routes)
+	if err != nil {
+		l.Fatal("Invalid route table", zap.Error(err))
+	}
+	routes := handlers.NewRoutes(table)
+	limiter := middleware.NewLimiter(cfg.RateLimit, l)
+
+	// Apply changes without a restart
+	dynconfig.BindLevel(dyn, level)
+	dynconfig.Subscribe(dyn, "RATE_LIMIT", limiter.SetRate)
+	dynconfig.Subscribe(dyn, "ROUTES", func(v string) {
+		table, err := handlers.ParseRoutes(v)
+		if err != nil {
+			l.Warn("Ignoring invalid route table", zap.Error(err))
+			return
+		}
+		routes.Set(table)
+	})
+	go dyn.Watch(context.Background())
 
 	// Set up Gin
 	r := gin.New()
 	r.Use(gin.Recovery())
-	r.Use(middleware.Logger(logger))
+	// Before the request logger, so that debug requests are logged at debug
+	// level, and before the limiter, so that limited requests are logged.
+	r.Use(logger.DebugRequests(l, []byte(cfg.LogDebugKey)))
+	r.Use(middleware.Logger(l))
 
-	// Add rate limiting middleware
-	r.Use(middleware.RateLimiter(cfg.RateLimit))
+	// Add rate limiting middleware. Routes registered before it are not limited.
+	r.Use(limiter.Handler())
+	logger.RegisterLevelRoutes(r, level, cfg.LogAdminToken)
 
 	// Set up routes
-	handlers.SetupRoutes(r, cfg)
+	handlers.SetupRoutes(r, routes, sd)
 
 	// Start server
-	logger.Info("Starting API Gateway", zap.String("port", cfg.Port))
-	if err := r.Run(":" + cfg.Port); err != nil {
-		logger.Fatal("Failed to start server", zap.Error(err))
+	l.Info("Starting API Gateway", zap.Int("port", cfg.Port))
+	if err := r.Run(":" + strconv.Itoa(cfg.Port)); err != nil {
+		l.Fatal("Failed to start server", zap.Error(err))
 	}
 }
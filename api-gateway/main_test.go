@@ -2,20 +2,30 @@ package main
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/MuxSphere/microkit/api-gateway/config"
 	"github.com/MuxSphere/microkit/api-gateway/handlers"
 	"github.com/MuxSphere/microkit/api-gateway/middleware"
+	sharedconfig "github.com/MuxSphere/microkit/shared/config"
+	"github.com/MuxSphere/microkit/shared/discovery"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest/observer"
 )
 
+func testRoutes() *handlers.Routes {
+	return handlers.NewRoutes(map[string]string{"service-a": "service-a", "service-b": "service-b"})
+}
+
 func setupRouter() (*gin.Engine, *observer.ObservedLogs) {
 	gin.SetMode(gin.TestMode)
 
@@ -29,12 +39,12 @@ func setupRouter() (*gin.Engine, *observer.ObservedLogs) {
 
 	cfg := &config.Config{
 		RateLimit: 10, // Set a rate limit for testing
-		Port:      "8080",
+		Port:      8080,
 	}
 
 	r.Use(middleware.RateLimiter(cfg.RateLimit))
 
-	handlers.SetupRoutes(r, cfg)
+	handlers.SetupRoutes(r, testRoutes(), discovery.NewMemoryRegistry())
 
 	return r, logs
 }
@@ -83,6 +93,7 @@ func TestRateLimiter(t *testing.T) {
 			assert.Equal(t, http.StatusOK, w.Code)
 		} else {
 			assert.Equal(t, http.StatusTooManyRequests, w.Code)
+			assert.Equal(t, "1", w.Header().Get("Retry-After"))
 		}
 	}
 
@@ -95,6 +106,32 @@ func TestRateLimiter(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 }
 
+func TestRateLimiterIgnoresInvalidRates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	core, logs := observer.New(zap.WarnLevel)
+	limiter := middleware.NewLimiter(2, zap.New(core))
+
+	r := gin.New()
+	r.Use(limiter.Handler())
+	r.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	// Values from Consul below 1 keep the previous rate
+	limiter.SetRate(0)
+	limiter.SetRate(-5)
+	assert.Equal(t, 2, logs.FilterMessage("Ignoring invalid rate limit").Len())
+
+	codes := make([]int, 3)
+	for i := range codes {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+		codes[i] = w.Code
+		if w.Code == http.StatusTooManyRequests {
+			assert.Equal(t, "1", w.Header().Get("Retry-After"))
+		}
+	}
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}, codes)
+}
+
 func TestInvalidRoute(t *testing.T) {
 	router, _ := setupRouter()
 
@@ -104,3 +141,91 @@ func TestInvalidRoute(t *testing.T) {
 
 	assert.Equal(t, http.StatusNotFound, w.Code)
 }
+
+func TestServiceUnavailable(t *testing.T) {
+	router, _ := setupRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/service-a/items", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	var problem map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, "unavailable", problem["code"])
+	assert.Equal(t, "/service-a/items", problem["instance"])
+	assert.Equal(t, true, problem["retryable"])
+}
+
+func TestVersionRouting(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	backend := func(body string) (string, int) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body))
+		}))
+		t.Cleanup(srv.Close)
+		u, _ := url.Parse(srv.URL)
+		port, _ := strconv.Atoi(u.Port())
+		return u.Hostname(), port
+	}
+
+	reg := discovery.NewMemoryRegistry()
+	host, port := backend("v1")
+	reg.RegisterService("service-a", host, port, discovery.WithVersion("1"))
+	host, port = backend("v2")
+	reg.RegisterService("service-a", host, port, discovery.WithVersion("2"))
+
+	r := gin.New()
+	handlers.SetupRoutes(r, testRoutes(), reg)
+	gateway := httptest.NewServer(r)
+	defer gateway.Close()
+
+	get := func(version string) *http.Response {
+		req, _ := http.NewRequest("GET", gateway.URL+"/service-a/items", nil)
+		req.Header.Set(handlers.VersionHeader, version)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		return resp
+	}
+
+	for _, version := range []string{"1", "2", "2", "1"} {
+		resp := get(version)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, "v"+version, string(body))
+	}
+
+	resp := get("3")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
+
+func TestParseRoutes(t *testing.T) {
+	table, err := handlers.ParseRoutes("service-a=service-a, /orders/ = service-b,")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"service-a": "service-a", "orders": "service-b"}, table)
+
+	_, err = handlers.ParseRoutes("service-a")
+	assert.Error(t, err)
+	_, err = handlers.ParseRoutes("a/b=service-a")
+	assert.Error(t, err)
+}
+
+func TestConfigRejectsPlaceholderSecret(t *testing.T) {
+	t.Setenv("JWT_SECRET", config.InsecureJWTSecret)
+
+	_, err := config.Load(sharedconfig.New())
+	assert.EqualError(t, err, "JWT_SECRET: the placeholder secret is only allowed with APP_ENV=development")
+
+	t.Setenv("APP_ENV", config.Development)
+	cfg, err := config.Load(sharedconfig.New())
+	require.NoError(t, err)
+	assert.Equal(t, config.InsecureJWTSecret, cfg.JWTSecret)
+
+	t.Setenv("APP_ENV", "production")
+	t.Setenv("JWT_SECRET", "")
+	_, err = config.Load(sharedconfig.New())
+	assert.EqualError(t, err, "JWT_SECRET: is required")
+}
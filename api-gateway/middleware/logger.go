@@ -3,11 +3,12 @@ package middleware
 import (
 	"time"
 
+	"github.com/MuxSphere/microkit/shared/logger"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
-func Logger(logger *zap.Logger) gin.HandlerFunc {
+func Logger(l *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 		path := c.Request.URL.Path
@@ -18,7 +19,7 @@ func Logger(logger *zap.Logger) gin.HandlerFunc {
 		latency := time.Since(start)
 		status := c.Writer.Status()
 
-		logger.Info("Request",
+		logger.FromContext(c.Request.Context(), l).Info("Request",
 			zap.Int("status", status),
 			zap.String("method", c.Request.Method),
 			zap.String("path", path),
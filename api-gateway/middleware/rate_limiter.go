@@ -1,18 +1,48 @@
 package middleware
 
 import (
-	"net/http"
+	"time"
 
+	apperrors "github.com/MuxSphere/microkit/shared/errors"
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 	"golang.org/x/time/rate"
 )
 
 func RateLimiter(rps int) gin.HandlerFunc {
-	limiter := rate.NewLimiter(rate.Limit(rps), rps)
+	return NewLimiter(rps, zap.NewNop()).Handler()
+}
+
+// Limiter is a rate limiter whose rate can be changed while serving.
+type Limiter struct {
+	limiter *rate.Limiter
+	logger  *zap.Logger
+}
+
+func NewLimiter(rps int, logger *zap.Logger) *Limiter {
+	return &Limiter{limiter: rate.NewLimiter(rate.Limit(rps), rps), logger: logger}
+}
+
+// SetRate changes the allowed requests per second and the burst size. Rates
+// below 1 are ignored and the current rate is kept.
+func (l *Limiter) SetRate(rps int) {
+	if rps < 1 {
+		l.logger.Warn("Ignoring invalid rate limit", zap.Int("rps", rps), zap.Float64("current", float64(l.limiter.Limit())))
+		return
+	}
+	l.limiter.SetLimit(rate.Limit(rps))
+	l.limiter.SetBurst(rps)
+}
+
+func (l *Limiter) Handler() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if !limiter.Allow() {
-			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests"})
-			c.Abort()
+		if !l.limiter.Allow() {
+			// One token is refilled every 1/rps seconds
+			retryAfter := time.Second
+			if limit := l.limiter.Limit(); limit > 0 && limit != rate.Inf {
+				retryAfter = time.Duration(float64(time.Second) / float64(limit))
+			}
+			apperrors.WriteProblem(c, apperrors.RateLimited("Too many requests", retryAfter))
 			return
 		}
 		c.Next()
@@ -0,0 +1,13 @@
+#!/bin/bash
+
+# Prints an X-Debug-Log header value signed with LOG_DEBUG_KEY that turns on
+# debug logging for requests until it expires, after $1 seconds (default 600).
+#   curl -H "X-Debug-Log: $(LOG_DEBUG_KEY=... scripts/debug-token.sh)" ...
+
+set -e
+
+: "${LOG_DEBUG_KEY:?LOG_DEBUG_KEY must be set}"
+
+exp=$(( $(date +%s) + ${1:-600} ))
+sig=$(printf '%s' "$exp" | openssl dgst -sha256 -hmac "$LOG_DEBUG_KEY" -binary | base64 | tr '+/' '-_' | tr -d '=\n')
+echo "$exp.$sig"
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/MuxSphere/microkit/proto"
+)
+
+// chatRooms fans chat messages out to the sessions in each room.
+type chatRooms struct {
+	mu     sync.Mutex
+	rooms  map[string]map[*chatSession]struct{}
+	buffer int
+}
+
+// chatSession is one member of a room. Messages queue in out; when it is full
+// the session is dropped and dropped is closed, so a slow client never blocks
+// the others.
+type chatSession struct {
+	out     chan *proto.ChatMessage
+	dropped chan struct{}
+}
+
+func newChatRooms(buffer int) *chatRooms {
+	return &chatRooms{rooms: make(map[string]map[*chatSession]struct{}), buffer: buffer}
+}
+
+func (c *chatRooms) join(room string) *chatSession {
+	s := &chatSession{
+		out:     make(chan *proto.ChatMessage, c.buffer),
+		dropped: make(chan struct{}),
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.rooms[room] == nil {
+		c.rooms[room] = make(map[*chatSession]struct{})
+	}
+	c.rooms[room][s] = struct{}{}
+	return s
+}
+
+func (c *chatRooms) leave(room string, s *chatSession) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.rooms[room], s)
+	if len(c.rooms[room]) == 0 {
+		delete(c.rooms, room)
+	}
+}
+
+func (c *chatRooms) broadcast(room string, msg *proto.ChatMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for s := range c.rooms[room] {
+		select {
+		case s.out <- msg:
+		default:
+			delete(c.rooms[room], s)
+			close(s.dropped)
+		}
+	}
+}
@@ -2,17 +2,28 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net"
+	"strconv"
 
 	"github.com/MuxSphere/microkit/proto"
+	"github.com/MuxSphere/microkit/service-a/items"
+	"github.com/MuxSphere/microkit/shared/database"
+	apperrors "github.com/MuxSphere/microkit/shared/errors"
+	"github.com/MuxSphere/microkit/shared/grpcserver"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type grpcServer struct {
 	proto.UnimplementedGreeterServiceServer
 	logger *zap.Logger
+	rooms  *chatRooms
 }
 
 func (s *grpcServer) SayHello(ctx context.Context, req *proto.HelloRequest) (*proto.HelloReply, error) {
@@ -20,16 +31,197 @@ func (s *grpcServer) SayHello(ctx context.Context, req *proto.HelloRequest) (*pr
 	return &proto.HelloReply{Message: fmt.Sprintf("Hello, %s!", req.Name)}, nil
 }
 
-func startGRPCServer(logger *zap.Logger, port string) error {
-	lis, err := net.Listen("tcp", ":"+port)
+func (s *grpcServer) Chat(stream proto.GreeterService_ChatServer) error {
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if first.Room == "" {
+		return apperrors.InvalidArgument("the first message must name a room", apperrors.FieldViolation{Field: "room", Description: "is required"})
+	}
+	room, sender := first.Room, first.Sender
+	session := s.rooms.join(room)
+	defer s.rooms.leave(room, session)
+
+	// Receive in the background so that sending and receiving do not wait on
+	// each other. The goroutine ends when the stream does.
+	recvErr := make(chan error, 1)
+	go func() {
+		msg := first
+		for {
+			if msg.Text != "" {
+				s.rooms.broadcast(room, &proto.ChatMessage{Room: room, Sender: sender, Text: msg.Text, SentAt: timestamppb.Now()})
+			}
+			if msg, err = stream.Recv(); err != nil {
+				recvErr <- err
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case msg := <-session.out:
+			// Blocks while the client's flow control window is full
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+		case <-session.dropped:
+			return apperrors.New(apperrors.CodeRateLimited, "chat session fell behind")
+		case err := <-recvErr:
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+// itemServer exposes items.Service over gRPC, mirroring the REST endpoints.
+// Service errors are converted to statuses by the server's interceptors.
+type itemServer struct {
+	proto.UnimplementedItemServiceServer
+	svc *items.Service
+}
+
+func (s *itemServer) CreateItem(ctx context.Context, req *proto.CreateItemRequest) (*proto.Item, error) {
+	item, err := s.svc.Create(ctx, items.Input{Name: req.Name, Description: req.Description, Quantity: int(req.Quantity)})
+	if err != nil {
+		return nil, err
+	}
+	return itemToProto(item), nil
+}
+
+func (s *itemServer) GetItem(ctx context.Context, req *proto.GetItemRequest) (*proto.Item, error) {
+	item, err := s.svc.Get(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	return itemToProto(item), nil
+}
+
+func (s *itemServer) ListItems(ctx context.Context, req *proto.ListItemsRequest) (*proto.ListItemsResponse, error) {
+	opts := database.ListOptions{Limit: int(req.Limit), Cursor: req.Cursor, Filters: req.Filter}
+	opts.Sort, opts.Desc = database.ParseSort(req.Sort)
+	page, err := s.svc.List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	resp := &proto.ListItemsResponse{NextCursor: page.NextCursor}
+	for i := range page.Items {
+		resp.Items = append(resp.Items, itemToProto(&page.Items[i]))
+	}
+	return resp, nil
+}
+
+func (s *itemServer) UpdateItem(ctx context.Context, req *proto.UpdateItemRequest) (*proto.Item, error) {
+	in := items.Input{Name: req.Name, Description: req.Description, Quantity: int(req.Quantity)}
+	item, err := s.svc.Update(ctx, req.Id, int(req.Version), in)
+	if err != nil {
+		return nil, err
+	}
+	return itemToProto(item), nil
+}
+
+func (s *itemServer) DeleteItem(ctx context.Context, req *proto.DeleteItemRequest) (*emptypb.Empty, error) {
+	if err := s.svc.Delete(ctx, req.Id); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *itemServer) WatchItems(req *proto.WatchItemsRequest, stream proto.ItemService_WatchItemsServer) error {
+	w := s.svc.Watch(stream.Context())
+	// Tell the client the watch is established before the first change
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+	for change := range w.C {
+		if req.Id != "" && change.Item.ID != req.Id {
+			continue
+		}
+		// Blocks while the client's flow control window is full; the watcher
+		// buffers changes meanwhile
+		if err := stream.Send(&proto.ItemEvent{Type: eventTypes[change.Type], Item: itemToProto(&change.Item)}); err != nil {
+			return err
+		}
+	}
+	if errors.Is(w.Err(), items.ErrSlowWatcher) {
+		return apperrors.Wrap(w.Err(), apperrors.CodeRateLimited, "watcher fell behind")
+	}
+	return w.Err()
+}
+
+var eventTypes = map[string]proto.ItemEvent_Type{
+	items.Created{}.EventType(): proto.ItemEvent_CREATED,
+	items.Updated{}.EventType(): proto.ItemEvent_UPDATED,
+	items.Deleted{}.EventType(): proto.ItemEvent_DELETED,
+}
+
+// ImportItems handles one message at a time, so a fast client is held back
+// by flow control rather than buffered in memory. Invalid items are reported
+// and skipped; other errors abort the import.
+func (s *itemServer) ImportItems(stream proto.ItemService_ImportItemsServer) error {
+	resp := &proto.ImportItemsResponse{}
+	for index := int32(0); ; index++ {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(resp)
+		}
+		// The validation interceptor rejects a message without ending the
+		// stream
+		if apperrors.HasCode(err, apperrors.CodeInvalidArgument) {
+			resp.Errors = append(resp.Errors, &proto.ImportError{Index: index, Message: apperrors.From(err).Message})
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		_, err = s.svc.Create(stream.Context(), items.Input{Name: req.Name, Description: req.Description, Quantity: int(req.Quantity)})
+		switch {
+		case err == nil:
+			resp.Created++
+		case apperrors.HasCode(err, apperrors.CodeInvalidArgument):
+			resp.Errors = append(resp.Errors, &proto.ImportError{Index: index, Message: apperrors.From(err).Message})
+		default:
+			return err
+		}
+	}
+}
+
+func itemToProto(item *items.Item) *proto.Item {
+	return &proto.Item{
+		Id:          item.ID,
+		Name:        item.Name,
+		Description: item.Description,
+		Quantity:    int32(item.Quantity),
+		Version:     int64(item.Version),
+		CreatedAt:   timestamppb.New(item.CreatedAt),
+		UpdatedAt:   timestamppb.New(item.UpdatedAt),
+	}
+}
+
+func newGRPCServer(logger *zap.Logger, svc *items.Service) *grpc.Server {
+	s := grpcserver.New(logger)
+	proto.RegisterGreeterServiceServer(s, &grpcServer{logger: logger, rooms: newChatRooms(64)})
+	proto.RegisterItemServiceServer(s, &itemServer{svc: svc})
+	return s
+}
+
+func startGRPCServer(logger *zap.Logger, port int, svc *items.Service) error {
+	lis, err := net.Listen("tcp", ":"+strconv.Itoa(port))
 	if err != nil {
 		return fmt.Errorf("failed to listen: %v", err)
 	}
 
-	s := grpc.NewServer()
-	proto.RegisterGreeterServiceServer(s, &grpcServer{logger: logger})
+	s := newGRPCServer(logger, svc)
 
-	logger.Info("Starting gRPC server", zap.String("port", port))
+	logger.Info("Starting gRPC server", zap.Int("port", port))
 	if err := s.Serve(lis); err != nil {
 		return fmt.Errorf("failed to serve: %v", err)
 	}
@@ -3,19 +3,31 @@ package handlers
 import (
 	"net/http"
 
+	"github.com/MuxSphere/microkit/service-a/items"
+	"github.com/MuxSphere/microkit/shared/database"
 	"github.com/gin-gonic/gin"
-	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
 )
 
-func RegisterRoutes(r *gin.Engine, db *sqlx.DB, logger *zap.Logger) {
-	r.GET("/health", healthCheck)
+func RegisterRoutes(r *gin.Engine, db database.DB, svc *items.Service, logger *zap.Logger) {
+	r.GET("/health", healthCheck(database.NewHealthChecker(db), logger))
+	registerItemRoutes(r, svc, logger)
 
 	// Add more routes here
 }
 
-func healthCheck(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"status": "ok",
-	})
+func healthCheck(checker *database.HealthChecker, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := checker.Check(c.Request.Context()); err != nil {
+			logger.Warn("Database health check failed", zap.Error(err))
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status":   "unavailable",
+				"database": "down",
+			})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"status": "ok",
+		})
+	}
 }
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/MuxSphere/microkit/service-a/items"
+	"github.com/MuxSphere/microkit/shared/database"
+	apperrors "github.com/MuxSphere/microkit/shared/errors"
+	"github.com/MuxSphere/microkit/shared/logger"
+	"github.com/MuxSphere/microkit/shared/validation"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type itemHandlers struct {
+	svc    *items.Service
+	logger *zap.Logger
+}
+
+// updateItemRequest carries the version the client read, for optimistic
+// locking.
+type updateItemRequest struct {
+	items.Input
+	Version int `json:"version" binding:"required,gte=1"`
+}
+
+func registerItemRoutes(r gin.IRouter, svc *items.Service, logger *zap.Logger) {
+	h := &itemHandlers{svc: svc, logger: logger}
+	r.GET("/items", h.list)
+	r.POST("/items", validation.JSON[items.Input](), h.create)
+	r.GET("/items/:id", h.get)
+	r.PUT("/items/:id", validation.JSON[updateItemRequest](), h.update)
+	r.DELETE("/items/:id", h.delete)
+}
+
+func (h *itemHandlers) list(c *gin.Context) {
+	opts, err := database.ParseListOptions(c)
+	if err != nil {
+		h.fail(c, apperrors.InvalidArgument(err.Error()))
+		return
+	}
+	page, err := h.svc.List(c.Request.Context(), opts)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *itemHandlers) create(c *gin.Context) {
+	item, err := h.svc.Create(c.Request.Context(), *validation.Body[items.Input](c))
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, item)
+}
+
+func (h *itemHandlers) get(c *gin.Context) {
+	item, err := h.svc.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+func (h *itemHandlers) update(c *gin.Context) {
+	req := validation.Body[updateItemRequest](c)
+	item, err := h.svc.Update(c.Request.Context(), c.Param("id"), req.Version, req.Input)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+func (h *itemHandlers) delete(c *gin.Context) {
+	if err := h.svc.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		h.fail(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// fail answers with err as problem details. Unexpected errors are logged, as
+// the client only sees a generic message.
+func (h *itemHandlers) fail(c *gin.Context, err error) {
+	if apperrors.CodeOf(err) == apperrors.CodeInternal {
+		logger.FromContext(c.Request.Context(), h.logger).Error("Item request failed", zap.String("path", c.FullPath()), zap.Error(err))
+	}
+	apperrors.WriteProblem(c, err)
+}
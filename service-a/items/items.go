@@ -0,0 +1,163 @@
+package items
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/MuxSphere/microkit/shared/database"
+	apperrors "github.com/MuxSphere/microkit/shared/errors"
+	"github.com/MuxSphere/microkit/shared/outbox"
+	"github.com/MuxSphere/microkit/shared/rabbitmq"
+	"github.com/MuxSphere/microkit/shared/validation"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type Item struct {
+	ID          string    `db:"id" json:"id"`
+	Name        string    `db:"name" json:"name"`
+	Description string    `db:"description" json:"description"`
+	Quantity    int       `db:"quantity" json:"quantity"`
+	Version     int       `db:"version" json:"version"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// Input holds the fields a client may set on an item. The binding rules
+// match CreateItemRequest in service.proto.
+type Input struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description" binding:"max=1000"`
+	Quantity    int    `json:"quantity" binding:"gte=0"`
+}
+
+// Validate returns an invalid argument error listing every invalid field.
+func (in Input) Validate() error {
+	return validation.Struct(in)
+}
+
+type Created struct {
+	Item Item `json:"item"`
+}
+
+func (Created) EventType() string { return "item.created" }
+
+type Updated struct {
+	Item Item `json:"item"`
+}
+
+func (Updated) EventType() string { return "item.updated" }
+
+type Deleted struct {
+	ID string `json:"id"`
+}
+
+func (Deleted) EventType() string { return "item.deleted" }
+
+// Service implements the item use cases shared by the REST and gRPC APIs.
+// Every change is stored together with its event in the outbox. Errors are
+// *apperrors.Error values, or unexpected errors the transports report as
+// internal.
+type Service struct {
+	db   database.DB
+	repo *database.Repository[Item]
+	bus  *rabbitmq.EventBus
+	hub  hub
+
+	// WatchBuffer is the number of changes a Watcher may fall behind.
+	WatchBuffer int
+}
+
+func NewService(db database.DB, bus *rabbitmq.EventBus) *Service {
+	bus.Register(Created{}, 1)
+	bus.Register(Updated{}, 1)
+	bus.Register(Deleted{}, 1)
+
+	repo := database.NewRepository[Item](db, "items")
+	repo.Generated = []string{"created_at"}
+	repo.Sortable = []string{"name", "quantity", "created_at"}
+	repo.Filterable = []string{"name"}
+	repo.DefaultSort = "created_at"
+	return &Service{db: db, repo: repo, bus: bus, WatchBuffer: 64}
+}
+
+func (s *Service) Create(ctx context.Context, in Input) (*Item, error) {
+	if err := in.Validate(); err != nil {
+		return nil, err
+	}
+	id := uuid.NewString()
+	var item Item
+	err := database.WithTx(ctx, s.db, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+		// Rebuilt on every attempt, as the insert overwrites it
+		item = Item{ID: id, Name: in.Name, Description: in.Description, Quantity: in.Quantity, UpdatedAt: time.Now().UTC()}
+		if err := s.repo.Insert(ctx, &item); err != nil {
+			return err
+		}
+		return outbox.Add(ctx, tx, s.bus, item.ID, Created{Item: item})
+	})
+	if err != nil {
+		return nil, translate(err)
+	}
+	s.hub.notify(Change{Type: Created{}.EventType(), Item: item})
+	return &item, nil
+}
+
+func (s *Service) Get(ctx context.Context, id string) (*Item, error) {
+	item, err := s.repo.Get(ctx, id)
+	return item, translate(err)
+}
+
+func (s *Service) List(ctx context.Context, opts database.ListOptions) (*database.Page[Item], error) {
+	page, err := s.repo.List(ctx, opts)
+	return page, translate(err)
+}
+
+// Update replaces the fields of item id. version must be the version the
+// client read, otherwise database.ErrConflict is returned.
+func (s *Service) Update(ctx context.Context, id string, version int, in Input) (*Item, error) {
+	if err := in.Validate(); err != nil {
+		return nil, err
+	}
+	var item Item
+	err := database.WithTx(ctx, s.db, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+		// Rebuilt on every attempt, as the update bumps the version
+		item = Item{ID: id, Name: in.Name, Description: in.Description, Quantity: in.Quantity, Version: version, UpdatedAt: time.Now().UTC()}
+		if err := s.repo.Update(ctx, &item); err != nil {
+			return err
+		}
+		return outbox.Add(ctx, tx, s.bus, item.ID, Updated{Item: item})
+	})
+	if err != nil {
+		return nil, translate(err)
+	}
+	s.hub.notify(Change{Type: Updated{}.EventType(), Item: item})
+	return &item, nil
+}
+
+func (s *Service) Delete(ctx context.Context, id string) error {
+	err := database.WithTx(ctx, s.db, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+		if err := s.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return outbox.Add(ctx, tx, s.bus, id, Deleted{ID: id})
+	})
+	if err != nil {
+		return translate(err)
+	}
+	s.hub.notify(Change{Type: Deleted{}.EventType(), Item: Item{ID: id}})
+	return nil
+}
+
+// translate maps repository errors to application errors.
+func translate(err error) error {
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		return apperrors.NotFound("item not found")
+	case errors.Is(err, database.ErrConflict):
+		return apperrors.Conflict("item was modified, reload and retry")
+	case database.IsInvalidListOptions(err):
+		return apperrors.InvalidArgument(err.Error())
+	}
+	return err
+}
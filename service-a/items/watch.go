@@ -0,0 +1,84 @@
+package items
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrSlowWatcher ends a Watcher whose receiver fell more than WatchBuffer
+// changes behind.
+var ErrSlowWatcher = errors.New("watcher fell behind")
+
+// Change is a committed change to an item. Type is the event type, e.g.
+// "item.updated". Deleted items only carry their ID.
+type Change struct {
+	Type string
+	Item Item
+}
+
+// Watcher receives the changes committed through one Service. C is closed
+// when the watch ends, after which Err reports why.
+type Watcher struct {
+	C   <-chan Change
+	ch  chan Change
+	err error
+}
+
+func (w *Watcher) Err() error {
+	return w.err
+}
+
+type hub struct {
+	mu       sync.Mutex
+	watchers map[*Watcher]struct{}
+}
+
+// Watch returns a Watcher for changes committed after the call, until ctx is
+// done. Changes made through other replicas are not seen; subscribe to the
+// item events for those.
+func (s *Service) Watch(ctx context.Context) *Watcher {
+	ch := make(chan Change, s.WatchBuffer)
+	w := &Watcher{C: ch, ch: ch}
+
+	s.hub.mu.Lock()
+	if s.hub.watchers == nil {
+		s.hub.watchers = make(map[*Watcher]struct{})
+	}
+	s.hub.watchers[w] = struct{}{}
+	s.hub.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		s.hub.remove(w, ctx.Err())
+	}()
+	return w
+}
+
+// notify never blocks writers: a watcher whose buffer is full is dropped.
+func (h *hub) notify(change Change) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for w := range h.watchers {
+		select {
+		case w.ch <- change:
+		default:
+			h.removeLocked(w, ErrSlowWatcher)
+		}
+	}
+}
+
+func (h *hub) remove(w *Watcher, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.removeLocked(w, err)
+}
+
+func (h *hub) removeLocked(w *Watcher, err error) {
+	if _, ok := h.watchers[w]; !ok {
+		return
+	}
+	delete(h.watchers, w)
+	w.err = err
+	close(w.ch)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -12,9 +13,16 @@ import (
 
 	"github.com/MuxSphere/microkit/service-a/config"
 	"github.com/MuxSphere/microkit/service-a/handlers"
+	"github.com/MuxSphere/microkit/service-a/items"
+	"github.com/MuxSphere/microkit/service-a/migrations"
+	sharedconfig "github.com/MuxSphere/microkit/shared/config"
 	"github.com/MuxSphere/microkit/shared/database"
 	"github.com/MuxSphere/microkit/shared/discovery"
+	"github.com/MuxSphere/microkit/shared/dynconfig"
+	"github.com/MuxSphere/microkit/shared/inbox"
+	"github.com/MuxSphere/microkit/shared/lock"
 	"github.com/MuxSphere/microkit/shared/logger"
+	"github.com/MuxSphere/microkit/shared/outbox"
 	"github.com/MuxSphere/microkit/shared/rabbitmq"
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus"
@@ -37,22 +45,81 @@ func init() {
 	prometheus.MustRegister(httpRequestsTotal)
 }
 
+// loadMigrations returns the service's migrations and those of the shared
+// packages it uses.
+func loadMigrations() ([]database.Migration, error) {
+	schema, err := database.LoadMigrations(migrations.FS, ".")
+	if err != nil {
+		return nil, err
+	}
+	return append(schema, inbox.Migration(3)), nil
+}
+
+// splitArgs separates the configuration flags from the arguments of the
+// migrate subcommand, which follow the first "migrate" argument.
+func splitArgs(args []string) (flags, migrate []string, isMigrate bool) {
+	for i, arg := range args {
+		if arg == "migrate" {
+			return args[:i], args[i+1:], true
+		}
+	}
+	return args, nil, false
+}
+
 func main() {
-	// Initialize configuration
-	cfg, err := config.Load()
+	// Initialize configuration from the environment, files and flags
+	flags, migrateArgs, isMigrate := splitArgs(os.Args[1:])
+	loader := sharedconfig.New()
+	loader.Args = flags
+	cfg, err := config.Load(loader)
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	// Initialize logger
-	l := logger.New(cfg.LogLevel)
+	// Initialize logger with a level that can change at runtime
+	level := zap.NewAtomicLevelAt(cfg.LogLevel)
+	l, err := logger.NewWithLevel(level)
+	if err != nil {
+		log.Fatalf("Failed to create logger: %v", err)
+	}
+	l.Info("Loaded configuration", zap.Any("config", sharedconfig.Redacted(cfg)))
 
-	// Initialize database connection
-	db, err := database.NewConnection(cfg.DatabaseURL)
+	// Initialize database connection, re-reading rotated credentials for new
+	// connections when DATABASE_URL references a secret
+	cfg.DB.Refresh = loader.Secrets.Refresh
+	cluster, err := database.ConnectCluster(context.Background(), cfg.DatabaseURL, cfg.DatabaseReplicaURLs, cfg.DB, l)
 	if err != nil {
 		l.Fatal("Failed to connect to database", zap.Error(err))
 	}
-	defer db.Close()
+	defer cluster.Close()
+	db := cluster.Primary()
+	if err := database.RegisterMetrics(prometheus.DefaultRegisterer, db, database.Name(cfg.DatabaseURL)); err != nil {
+		l.Fatal("Failed to register database metrics", zap.Error(err))
+	}
+	for i, replica := range cluster.Replicas() {
+		name := fmt.Sprintf("%s_replica_%d", database.Name(cfg.DatabaseReplicaURLs[i]), i)
+		if err := database.RegisterMetrics(prometheus.DefaultRegisterer, replica, name); err != nil {
+			l.Fatal("Failed to register database metrics", zap.Error(err))
+		}
+	}
+
+	// Apply schema migrations, or run the migrate subcommand and exit
+	schema, err := loadMigrations()
+	if err != nil {
+		l.Fatal("Failed to load migrations", zap.Error(err))
+	}
+	migrator := database.NewMigrator(db, schema, l)
+	if isMigrate {
+		if err := database.RunMigrateCommand(context.Background(), migrator, migrateArgs, os.Stdout); err != nil {
+			l.Fatal("Migration failed", zap.Error(err))
+		}
+		return
+	}
+	if cfg.AutoMigrate {
+		if _, err := migrator.Up(context.Background()); err != nil {
+			l.Fatal("Failed to apply migrations", zap.Error(err))
+		}
+	}
 
 	// Initialize RabbitMQ
 	rabbitMQ, err := rabbitmq.New(cfg.RabbitMQURL, l)
@@ -77,44 +144,75 @@ func main() {
 	}
 
 	// Sets up service discovery
-	sd, err := discovery.NewServiceDiscovery(cfg.ConsulAddr)
+	sd, err := discovery.Open(cfg.DiscoveryURL, nil)
 	if err != nil {
 		l.Fatal("Failed to create service discovery client", zap.Error(err))
 	}
 
-	// Register service with Consul
-	port, err := strconv.Atoi(cfg.Port)
-	if err != nil {
-		l.Fatal("Failed to convert port to int", zap.Error(err))
+	// Follow settings in Consul KV under config/<service name>/
+	var kv dynconfig.KV
+	if consul, ok := sd.(*discovery.ServiceDiscovery); ok {
+		kv = consul.Client().KV()
+	}
+	dyn := dynconfig.New(kv, "config/"+cfg.ServiceName, l)
+	dyn.Viper = loader.Viper()
+	if err := dyn.Load(context.Background()); err != nil {
+		l.Warn("Failed to load configuration from Consul", zap.Error(err))
 	}
+	dynconfig.BindLevel(dyn, level)
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+	go dyn.Watch(watchCtx)
 
-	err = sd.RegisterService(cfg.ServiceName, cfg.Host, port)
-	if err != nil {
-		l.Fatal("Failed to register service with Consul", zap.Error(err))
+	// Singleton jobs are guarded by Consul locks, or Postgres advisory locks
+	// when Consul is not used
+	var locker lock.Locker = lock.NewPostgresLocker(db)
+	if consul, ok := sd.(*discovery.ServiceDiscovery); ok {
+		locker = lock.NewConsulLocker(consul.Client())
 	}
-	defer sd.DeregisterService(cfg.ServiceName, cfg.Host, port) // Deregister on shutdown
 
-	// Initialize Gin router and Prometheus middleware
-	r := gin.New()
-	r.Use(gin.Recovery())
-	r.Use(logger.GinMiddleware(l))
-	r.Use(prometheusMiddleware())
+	// Relay events written to the transactional outbox on the elected replica
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	relay := outbox.NewRelay(db, rabbitMQ, l)
+	go lock.NewElector(locker, cfg.ServiceName+"/outbox-relay", l).Run(jobsCtx, relay.Run)
 
-	// Register Prometheus metrics endpoint
-	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	// Exclude failing or lagging read replicas
+	go cluster.Run(jobsCtx)
 
-	// Register routes
-	handlers.RegisterRoutes(r, db, l)
+	// Register service with the registry
+	deregister, err := registerService(sd, cfg)
+	if err != nil {
+		l.Fatal("Failed to register service", zap.Error(err))
+	}
+	defer deregister() // Deregister on shutdown
+
+	// Record latency, slow queries and spans for the handlers' queries
+	ins, err := database.NewInstrumentation(prometheus.DefaultRegisterer, database.Name(cfg.DatabaseURL), l)
+	if err != nil {
+		l.Fatal("Failed to register query metrics", zap.Error(err))
+	}
+	ins.SlowThreshold = cfg.SlowQueryThreshold
+	instrumented := ins.InstrumentDB(cluster)
+
+	// Item changes are published as events through the outbox
+	bus, err := rabbitmq.NewEventBus(rabbitMQ, cfg.EventsExchange, cfg.ServiceName, rabbitmq.ContentTypeJSON, l)
+	if err != nil {
+		l.Fatal("Failed to create event bus", zap.Error(err))
+	}
+	itemService := items.NewService(instrumented, bus)
+	r := newRouter(instrumented, itemService, l, []byte(cfg.LogDebugKey))
+	logger.RegisterLevelRoutes(r, level, cfg.LogAdminToken)
 
 	// Create HTTP server
 	srv := &http.Server{
-		Addr:    ":" + cfg.Port,
+		Addr:    ":" + strconv.Itoa(cfg.Port),
 		Handler: r,
 	}
 
 	// Start HTTP server
 	go func() {
-		l.Info("Starting HTTP server", zap.String("port", cfg.Port))
+		l.Info("Starting HTTP server", zap.Int("port", cfg.Port))
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			l.Fatal("Failed to start HTTP server", zap.Error(err))
 		}
@@ -122,7 +220,7 @@ func main() {
 
 	// Start gRPC server
 	go func() {
-		if err := startGRPCServer(l, cfg.GRPCPort); err != nil {
+		if err := startGRPCServer(l, cfg.GRPCPort, itemService); err != nil {
 			l.Fatal("Failed to start gRPC server", zap.Error(err))
 		}
 	}()
@@ -142,6 +240,46 @@ func main() {
 	l.Info("Server exiting")
 }
 
+// registerService announces the service and returns a function that removes
+// the registration again.
+func registerService(reg discovery.Registry, cfg *config.Config) (func() error, error) {
+	opts := []discovery.RegisterOption{
+		discovery.WithVersion(cfg.Version),
+		discovery.WithGRPCPort(cfg.GRPCPort),
+		discovery.WithTTL(cfg.CheckTTL),
+		discovery.WithDeregisterCriticalAfter(cfg.DeregisterAfter),
+	}
+	if cfg.InstanceID != "" {
+		opts = append(opts, discovery.WithID(cfg.InstanceID))
+	}
+	if cfg.Zone != "" {
+		opts = append(opts, discovery.WithZone(cfg.Zone))
+	}
+
+	if err := reg.RegisterService(cfg.ServiceName, cfg.Host, cfg.Port, opts...); err != nil {
+		return nil, err
+	}
+	return func() error {
+		return reg.DeregisterService(cfg.ServiceName, cfg.Host, cfg.Port, opts...)
+	}, nil
+}
+
+func newRouter(db database.DB, itemService *items.Service, l *zap.Logger, debugKey []byte) *gin.Engine {
+	// Initialize Gin router and Prometheus middleware
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.Use(logger.DebugRequests(l, debugKey))
+	r.Use(logger.GinMiddleware(l))
+	r.Use(prometheusMiddleware())
+
+	// Register Prometheus metrics endpoint
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	// Register routes
+	handlers.RegisterRoutes(r, db, itemService, l)
+	return r
+}
+
 // Prometheus middleware to track HTTP requests
 func prometheusMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS outbox (
+	id             BIGSERIAL PRIMARY KEY,
+	aggregate_id   TEXT NOT NULL,
+	exchange       TEXT NOT NULL,
+	routing_key    TEXT NOT NULL,
+	message_id     TEXT NOT NULL,
+	event_type     TEXT NOT NULL,
+	source         TEXT NOT NULL,
+	content_type   TEXT NOT NULL,
+	schema_version INT NOT NULL,
+	payload        BYTEA NOT NULL,
+	created_at     TIMESTAMPTZ NOT NULL,
+	published_at   TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS outbox_pending_idx ON outbox (aggregate_id, id) WHERE published_at IS NULL;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS items (
+	id          TEXT PRIMARY KEY,
+	name        TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	quantity    INT NOT NULL DEFAULT 0,
+	version     INT NOT NULL DEFAULT 1,
+	created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+	updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS items_name_idx ON items (name, id);
+CREATE INDEX IF NOT EXISTS items_created_at_idx ON items (created_at, id);
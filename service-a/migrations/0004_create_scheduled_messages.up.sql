@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS scheduled_messages (
+	id             TEXT PRIMARY KEY,
+	exchange       TEXT NOT NULL,
+	routing_key    TEXT NOT NULL,
+	message_id     TEXT NOT NULL,
+	message_type   TEXT NOT NULL,
+	content_type   TEXT NOT NULL,
+	headers        JSONB NOT NULL DEFAULT '{}',
+	timestamp      TIMESTAMPTZ,
+	app_id         TEXT NOT NULL DEFAULT '',
+	correlation_id TEXT NOT NULL DEFAULT '',
+	reply_to       TEXT NOT NULL DEFAULT '',
+	delivery_mode  SMALLINT NOT NULL DEFAULT 2,
+	body           BYTEA NOT NULL,
+	deliver_at     TIMESTAMPTZ NOT NULL,
+	status         TEXT NOT NULL DEFAULT 'pending',
+	created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+	updated_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS scheduled_messages_due_idx ON scheduled_messages (deliver_at) WHERE status = 'pending';
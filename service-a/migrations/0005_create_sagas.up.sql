@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS sagas (
+	id         TEXT PRIMARY KEY,
+	name       TEXT NOT NULL,
+	status     TEXT NOT NULL,
+	step       INT NOT NULL,
+	attempts   INT NOT NULL DEFAULT 0,
+	data       JSONB NOT NULL,
+	error      TEXT NOT NULL DEFAULT '',
+	deadline   TIMESTAMPTZ,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS sagas_active_deadline_idx ON sagas (deadline) WHERE status IN ('running', 'compensating');
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/MuxSphere/microkit/service-b/config"
+	"github.com/MuxSphere/microkit/service-b/worker"
+	sharedconfig "github.com/MuxSphere/microkit/shared/config"
+	"github.com/MuxSphere/microkit/shared/discovery"
+	"github.com/MuxSphere/microkit/shared/dynconfig"
+	"github.com/MuxSphere/microkit/shared/logger"
+	"github.com/MuxSphere/microkit/shared/rabbitmq"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/streadway/amqp"
+	"go.uber.org/zap"
+)
+
+func main() {
+	// Initialize configuration from the environment, files and flags
+	loader := sharedconfig.New()
+	loader.Args = os.Args[1:]
+	cfg, err := config.Load(loader)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	// Initialize logger with a level that can change at runtime
+	level := zap.NewAtomicLevelAt(cfg.LogLevel)
+	l, err := logger.NewWithLevel(level)
+	if err != nil {
+		log.Fatalf("Failed to create logger: %v", err)
+	}
+	l.Info("Loaded configuration", zap.Any("config", sharedconfig.Redacted(cfg)))
+
+	// Initialize RabbitMQ
+	rabbitMQ, err := rabbitmq.New(cfg.RabbitMQURL, l)
+	if err != nil {
+		l.Fatal("Failed to connect to RabbitMQ", zap.Error(err))
+	}
+	defer rabbitMQ.Close()
+
+	// Answer RPC calls from other services
+	if err := rabbitMQ.DeclareExchange(worker.Exchange, amqp.ExchangeDirect); err != nil {
+		l.Fatal("Failed to declare RPC exchange", zap.Error(err))
+	}
+	if err := rabbitMQ.DeclareQueue(worker.SumQueue, nil); err != nil {
+		l.Fatal("Failed to declare RPC queue", zap.Error(err))
+	}
+	if err := rabbitMQ.BindQueue(worker.SumQueue, worker.SumRoute, worker.Exchange, nil); err != nil {
+		l.Fatal("Failed to bind RPC queue", zap.Error(err))
+	}
+	if err := rabbitMQ.ServeRPC(worker.SumQueue, worker.Sum); err != nil {
+		l.Fatal("Failed to serve RPC calls", zap.Error(err))
+	}
+
+	// Sets up service discovery
+	sd, err := discovery.Open(cfg.DiscoveryURL, nil)
+	if err != nil {
+		l.Fatal("Failed to create service discovery client", zap.Error(err))
+	}
+
+	// Follow settings in Consul KV under config/<service name>/
+	var kv dynconfig.KV
+	if consul, ok := sd.(*discovery.ServiceDiscovery); ok {
+		kv = consul.Client().KV()
+	}
+	dyn := dynconfig.New(kv, "config/"+cfg.ServiceName, l)
+	dyn.Viper = loader.Viper()
+	if err := dyn.Load(context.Background()); err != nil {
+		l.Warn("Failed to load configuration from Consul", zap.Error(err))
+	}
+	dynconfig.BindLevel(dyn, level)
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+	go dyn.Watch(watchCtx)
+
+	// Register service with the registry
+	opts := []discovery.RegisterOption{
+		discovery.WithVersion(cfg.Version),
+		discovery.WithTTL(cfg.CheckTTL),
+		discovery.WithDeregisterCriticalAfter(cfg.DeregisterAfter),
+	}
+	if cfg.InstanceID != "" {
+		opts = append(opts, discovery.WithID(cfg.InstanceID))
+	}
+	if cfg.Zone != "" {
+		opts = append(opts, discovery.WithZone(cfg.Zone))
+	}
+
+	err = sd.RegisterService(cfg.ServiceName, cfg.Host, cfg.Port, opts...)
+	if err != nil {
+		l.Fatal("Failed to register service", zap.Error(err))
+	}
+	defer sd.DeregisterService(cfg.ServiceName, cfg.Host, cfg.Port, opts...) // Deregister on shutdown
+
+	// Initialize Gin router
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.Use(logger.DebugRequests(l, []byte(cfg.LogDebugKey)))
+	r.Use(logger.GinMiddleware(l))
+	logger.RegisterLevelRoutes(r, level, cfg.LogAdminToken)
+
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	r.GET("/health", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	srv := &http.Server{
+		Addr:    ":" + strconv.Itoa(cfg.Port),
+		Handler: r,
+	}
+
+	go func() {
+		l.Info("Starting HTTP server", zap.Int("port", cfg.Port))
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			l.Fatal("Failed to start HTTP server", zap.Error(err))
+		}
+	}()
+
+	// Graceful shutdown
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	l.Info("Shutting down server...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		l.Fatal("Server forced to shutdown", zap.Error(err))
+	}
+
+	l.Info("Server exiting")
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/MuxSphere/microkit/service-b/worker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSumHandler(t *testing.T) {
+	req, _ := json.Marshal(worker.SumRequest{Numbers: []int{1, 2, 3}})
+
+	body, err := worker.Sum(context.Background(), req)
+	assert.NoError(t, err)
+
+	var reply worker.SumReply
+	assert.NoError(t, json.Unmarshal(body, &reply))
+	assert.Equal(t, 6, reply.Sum)
+}
+
+func TestSumHandlerInvalidRequest(t *testing.T) {
+	_, err := worker.Sum(context.Background(), []byte("not json"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,36 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	// Exchange and queue service-b answers RPC calls on.
+	Exchange = "rpc"
+	SumQueue = "service-b.sum"
+	SumRoute = "sum"
+)
+
+type SumRequest struct {
+	Numbers []int `json:"numbers"`
+}
+
+type SumReply struct {
+	Sum int `json:"sum"`
+}
+
+// Sum is an example RPC handler: it adds up the numbers in the request.
+func Sum(ctx context.Context, req []byte) ([]byte, error) {
+	var in SumRequest
+	if err := json.Unmarshal(req, &in); err != nil {
+		return nil, fmt.Errorf("invalid sum request: %w", err)
+	}
+
+	var out SumReply
+	for _, n := range in.Numbers {
+		out.Sum += n
+	}
+	return json.Marshal(out)
+}
@@ -0,0 +1,346 @@
+// Package config loads typed service configuration. Every struct field is
+// bound to a key and filled from, in increasing order of precedence, its
+// default, configuration files, the environment and command-line flags. All
+// invalid values are reported together before the service starts.
+//
+// A value may reference a secret instead, e.g. JWT_SECRET=file:///run/secrets/jwt,
+// which is replaced by the secret when loading.
+//
+// Keys come from the config tag, or the field name in snake case. Nested
+// structs become sections, so DB.MaxOpenConns is the key db.max_open_conns,
+// the variable DB_MAX_OPEN_CONNS and the flag --db.max-open-conns:
+//
+//	type Config struct {
+//		Port      int      `config:"port" default:"8080" validate:"min=1,max=65535"`
+//		Peers     []string `config:"peers"`
+//		JWTSecret string   `config:"jwt_secret" secret:"true"`
+//	}
+package config
+
+import (
+	"context"
+	"encoding"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/MuxSphere/microkit/shared/secrets"
+	"github.com/MuxSphere/microkit/shared/validation"
+	"github.com/go-playground/validator/v10"
+	"github.com/joho/godotenv"
+	"github.com/spf13/cast"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+var validate = validator.New()
+
+var (
+	durationType  = reflect.TypeOf(time.Duration(0))
+	unmarshalType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	marshalType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Loader reads configuration into structs.
+type Loader struct {
+	v *viper.Viper
+
+	// Files are YAML, TOML or JSON files read in order, later files overriding
+	// earlier ones. Missing files are skipped. Defaults to the comma-separated
+	// list in CONFIG_FILE.
+	Files []string
+	// EnvFiles are dotenv files loaded into the environment. Variables that
+	// are already set win. Missing files are skipped.
+	EnvFiles []string
+	// Args are parsed as flags, one per key, plus --config to add a file.
+	// Nil disables flags.
+	Args []string
+	// Secrets resolves values that reference a secret. It reads file://
+	// references, and secret:// references from the JSON file named by
+	// SECRETS_FILE when that is set.
+	Secrets *secrets.Resolver
+}
+
+func New() *Loader {
+	resolver := secrets.NewResolver()
+	if path := os.Getenv("SECRETS_FILE"); path != "" {
+		resolver.Register("secret", secrets.NewFileStore(path))
+	}
+	return &Loader{
+		v:        viper.New(),
+		Files:    split(os.Getenv("CONFIG_FILE")),
+		EnvFiles: []string{".env"},
+		Secrets:  resolver,
+	}
+}
+
+// Viper returns the instance holding the loaded values. Values set on it, like
+// dynconfig overrides, take precedence over every source on the next Load.
+func (l *Loader) Viper() *viper.Viper {
+	return l.v
+}
+
+type field struct {
+	key   string
+	env   string
+	path  string
+	value reflect.Value
+	tag   reflect.StructTag
+}
+
+// Load fills the struct dst points to. Fields that already hold a value keep
+// it as their default unless they have a default tag.
+func (l *Loader) Load(dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("config: destination must be a pointer to a struct")
+	}
+	fields := collect(rv.Elem(), "", rv.Elem().Type().Name())
+
+	for _, f := range fields {
+		if def, ok := f.tag.Lookup("default"); ok {
+			l.v.SetDefault(f.key, def)
+		} else if !f.value.IsZero() {
+			l.v.SetDefault(f.key, format(f.value))
+		}
+		if err := l.v.BindEnv(f.key, f.env); err != nil {
+			return err
+		}
+	}
+
+	for _, path := range l.EnvFiles {
+		if err := godotenv.Load(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("config: %s: %w", path, err)
+		}
+	}
+
+	files := l.Files
+	if l.Args != nil {
+		extra, err := l.parseFlags(fields)
+		if err != nil {
+			return err
+		}
+		files = append(append([]string(nil), files...), extra...)
+	}
+	for _, path := range files {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+		l.v.SetConfigFile(path)
+		if err := l.v.MergeInConfig(); err != nil {
+			return fmt.Errorf("config: %s: %w", path, err)
+		}
+	}
+
+	var errs []error
+	failed := make(map[string]bool)
+	for _, f := range fields {
+		if err := l.assign(f.value, l.v.Get(f.key)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.env, err))
+			failed[f.path] = true
+		}
+	}
+
+	var verrs validator.ValidationErrors
+	if err := validate.Struct(dst); errors.As(err, &verrs) {
+		names := make(map[string]string, len(fields))
+		for _, f := range fields {
+			names[f.path] = f.env
+		}
+		for _, fe := range verrs {
+			if failed[fe.StructNamespace()] {
+				continue
+			}
+			name, ok := names[fe.StructNamespace()]
+			if !ok {
+				name = fe.Namespace()
+			}
+			errs = append(errs, fmt.Errorf("%s: %s", name, validation.Describe(fe)))
+		}
+	} else if err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
+
+func (l *Loader) parseFlags(fields []field) ([]string, error) {
+	fs := pflag.NewFlagSet(os.Args[0], pflag.ContinueOnError)
+	files := fs.StringArray("config", nil, "configuration file")
+	for _, f := range fields {
+		name := strings.ReplaceAll(f.key, "_", "-")
+		fs.String(name, "", "overrides $"+f.env)
+		if err := l.v.BindPFlag(f.key, fs.Lookup(name)); err != nil {
+			return nil, err
+		}
+	}
+	if err := fs.Parse(l.Args); err != nil {
+		return nil, err
+	}
+	return *files, nil
+}
+
+// Load reads dst with a default Loader.
+func Load(dst interface{}) error {
+	return New().Load(dst)
+}
+
+func collect(v reflect.Value, prefix, path string) []field {
+	var fields []field
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := sf.Tag.Get("config")
+		if !sf.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = snake(sf.Name)
+		}
+		f := field{
+			key:   prefix + name,
+			path:  path + "." + sf.Name,
+			value: v.Field(i),
+			tag:   sf.Tag,
+		}
+		if f.value.Kind() == reflect.Struct && !reflect.PointerTo(sf.Type).Implements(unmarshalType) {
+			fields = append(fields, collect(f.value, f.key+".", f.path)...)
+			continue
+		}
+		f.env = strings.ToUpper(strings.ReplaceAll(f.key, ".", "_"))
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// snake converts a Go field name to a key, e.g. GRPCPort to grpc_port.
+func snake(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// assign converts raw, a string from the environment or flags or a value
+// decoded from a file, and stores it in v.
+func (l *Loader) assign(v reflect.Value, raw interface{}) error {
+	if s, ok := raw.(string); raw == nil || ok && s == "" {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+
+	if v.Kind() == reflect.Slice {
+		items, ok := raw.([]interface{})
+		if !ok {
+			s, err := cast.ToStringE(raw)
+			if err != nil {
+				return err
+			}
+			for _, item := range split(s) {
+				items = append(items, item)
+			}
+		}
+		out := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := l.assign(out.Index(i), item); err != nil {
+				return err
+			}
+		}
+		v.Set(out)
+		return nil
+	}
+
+	s, err := cast.ToStringE(raw)
+	if err != nil {
+		return err
+	}
+	if l.Secrets != nil {
+		if s, err = l.Secrets.Resolve(context.Background(), s); err != nil {
+			return err
+		}
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(unmarshalType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("%q is not a valid duration", s)
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(s)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("%q is not a valid bool", s)
+		}
+		v.SetBool(b)
+	case v.CanInt():
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a valid integer", s)
+		}
+		v.SetInt(n)
+	case v.CanUint():
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a valid unsigned integer", s)
+		}
+		v.SetUint(n)
+	case v.CanFloat():
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a valid number", s)
+		}
+		v.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// format renders v the way assign reads it back.
+func format(v reflect.Value) string {
+	if v.Type().Implements(marshalType) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err == nil {
+			return string(text)
+		}
+	}
+	if v.Kind() == reflect.Slice {
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = format(v.Index(i))
+		}
+		return strings.Join(items, ",")
+	}
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String()
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func split(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,189 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+type pool struct {
+	MaxConns int
+	Timeout  time.Duration
+}
+
+type testConfig struct {
+	Port      int           `config:"port" default:"8080" validate:"min=1,max=65535"`
+	Name      string        `config:"name" validate:"required"`
+	Peers     []string      `config:"peers"`
+	Interval  time.Duration `config:"interval" default:"5s"`
+	Debug     bool          `config:"debug"`
+	Level     zapcore.Level `config:"level" default:"info"`
+	Secret    string        `config:"secret" secret:"true"`
+	URL       string        `config:"url"`
+	DB        pool          `config:"db"`
+	Ignored   string        `config:"-"`
+	GRPCPort  int
+	unexposed int
+}
+
+func newLoader() *Loader {
+	l := New()
+	l.Files = nil
+	l.EnvFiles = nil
+	return l
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadDefaultsAndEnv(t *testing.T) {
+	t.Setenv("NAME", "orders")
+	t.Setenv("PEERS", "a:1, b:2,")
+	t.Setenv("DB_MAX_CONNS", "7")
+	t.Setenv("LEVEL", "debug")
+	t.Setenv("GRPC_PORT", "9090")
+
+	cfg := testConfig{DB: pool{Timeout: time.Second}}
+	require.NoError(t, newLoader().Load(&cfg))
+
+	assert.Equal(t, 8080, cfg.Port)
+	assert.Equal(t, "orders", cfg.Name)
+	assert.Equal(t, []string{"a:1", "b:2"}, cfg.Peers)
+	assert.Equal(t, 5*time.Second, cfg.Interval)
+	assert.Equal(t, zapcore.DebugLevel, cfg.Level)
+	assert.Equal(t, 7, cfg.DB.MaxConns)
+	assert.Equal(t, time.Second, cfg.DB.Timeout)
+	assert.Equal(t, 9090, cfg.GRPCPort)
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	yaml := writeFile(t, "base.yaml", "name: from-yaml\nport: 9000\npeers: [x, y]\ndb:\n  max_conns: 3\n")
+	toml := writeFile(t, "override.toml", "port = 9100\ninterval = \"1m\"\n")
+	t.Setenv("PORT", "9200")
+	t.Setenv("DEBUG", "true")
+
+	l := newLoader()
+	l.Files = []string{yaml, filepath.Join(t.TempDir(), "missing.yaml")}
+	l.Args = []string{"--config", toml, "--port", "9300", "--db.max-conns=4"}
+
+	var cfg testConfig
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, "from-yaml", cfg.Name)
+	assert.Equal(t, []string{"x", "y"}, cfg.Peers)
+	assert.Equal(t, time.Minute, cfg.Interval)
+	assert.True(t, cfg.Debug)
+	assert.Equal(t, 9300, cfg.Port)
+	assert.Equal(t, 4, cfg.DB.MaxConns)
+
+	// Overrides set on the viper instance win over every source
+	l.Viper().Set("PORT", "9400")
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, 9400, cfg.Port)
+}
+
+func TestLoadEnvFile(t *testing.T) {
+	path := writeFile(t, ".env", "NAME=from-dotenv\nDB_MAX_CONNS=12\n")
+	t.Setenv("NAME", "from-env")
+	t.Setenv("DB_MAX_CONNS", "")
+	os.Unsetenv("DB_MAX_CONNS")
+
+	l := newLoader()
+	l.EnvFiles = []string{path}
+	var cfg testConfig
+	require.NoError(t, l.Load(&cfg))
+	assert.Equal(t, "from-env", cfg.Name)
+	assert.Equal(t, 12, cfg.DB.MaxConns)
+}
+
+func TestLoadReportsAllErrors(t *testing.T) {
+	t.Setenv("PORT", "abc")
+	t.Setenv("INTERVAL", "10")
+	t.Setenv("DEBUG", "maybe")
+	t.Setenv("LEVEL", "verbose")
+	t.Setenv("DB_MAX_CONNS", "many")
+
+	var cfg testConfig
+	err := newLoader().Load(&cfg)
+	require.Error(t, err)
+	assert.Equal(t, `PORT: "abc" is not a valid integer
+INTERVAL: "10" is not a valid duration
+DEBUG: "maybe" is not a valid bool
+LEVEL: unrecognized level: "verbose"
+DB_MAX_CONNS: "many" is not a valid integer
+NAME: is required`, err.Error())
+
+	t.Setenv("PORT", "0")
+	t.Setenv("INTERVAL", "")
+	t.Setenv("DEBUG", "")
+	t.Setenv("LEVEL", "")
+	t.Setenv("DB_MAX_CONNS", "")
+	t.Setenv("NAME", "orders")
+	err = newLoader().Load(&cfg)
+	assert.EqualError(t, err, "PORT: must be at least 1")
+}
+
+func TestLoadSecretReferences(t *testing.T) {
+	path := writeFile(t, "secret", "hunter2\n")
+	t.Setenv("NAME", "orders")
+	t.Setenv("SECRET", "file://"+path)
+	t.Setenv("URL", "file://"+path+".missing")
+
+	var cfg testConfig
+	err := newLoader().Load(&cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "URL: open "+path+".missing")
+
+	t.Setenv("URL", "postgres://db/app")
+	require.NoError(t, newLoader().Load(&cfg))
+	assert.Equal(t, "hunter2", cfg.Secret)
+	assert.Equal(t, "postgres://db/app", cfg.URL)
+}
+
+func TestLoadRejectsUnknownFlags(t *testing.T) {
+	l := newLoader()
+	l.Args = []string{"--nope"}
+	var cfg testConfig
+	assert.Error(t, l.Load(&cfg))
+	assert.Error(t, l.Load(cfg))
+}
+
+func TestSnake(t *testing.T) {
+	for name, want := range map[string]string{
+		"Port":            "port",
+		"GRPCPort":        "grpc_port",
+		"DatabaseURL":     "database_url",
+		"ConnMaxLifetime": "conn_max_lifetime",
+		"V2Name":          "v2_name",
+	} {
+		assert.Equal(t, want, snake(name), name)
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := testConfig{
+		Port:     8080,
+		Secret:   "hunter2",
+		URL:      "postgres://user:pass@db:5432/app",
+		Peers:    []string{"amqp://guest:guest@mq:5672/"},
+		Interval: time.Minute,
+		DB:       pool{MaxConns: 3},
+	}
+	out := Redacted(&cfg)
+
+	assert.Equal(t, 8080, out["port"])
+	assert.Equal(t, "[redacted]", out["secret"])
+	assert.Equal(t, "postgres://user:xxxxx@db:5432/app", out["url"])
+	assert.Equal(t, []string{"amqp://guest:xxxxx@mq:5672/"}, out["peers"])
+	assert.Equal(t, "1m0s", out["interval"])
+	assert.Equal(t, 3, out["db.max_conns"])
+	assert.NotContains(t, out, "ignored")
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"net/url"
+	"reflect"
+)
+
+const redacted = "[redacted]"
+
+// Redacted returns the effective configuration in cfg as a map from keys to
+// values, for logging at startup. Fields tagged secret:"true" are replaced
+// and passwords are removed from URLs.
+func Redacted(cfg interface{}) map[string]interface{} {
+	v := reflect.Indirect(reflect.ValueOf(cfg))
+	out := make(map[string]interface{})
+	for _, f := range collect(v, "", v.Type().Name()) {
+		switch {
+		case f.tag.Get("secret") == "true":
+			if !f.value.IsZero() {
+				out[f.key] = redacted
+			} else {
+				out[f.key] = ""
+			}
+		case f.value.Kind() == reflect.String:
+			out[f.key] = redactURL(f.value.String())
+		case f.value.Kind() == reflect.Slice && f.value.Type().Elem().Kind() == reflect.String:
+			items := make([]string, f.value.Len())
+			for i := range items {
+				items[i] = redactURL(f.value.Index(i).String())
+			}
+			out[f.key] = items
+		case f.value.Type() == durationType:
+			out[f.key] = format(f.value)
+		default:
+			out[f.key] = f.value.Interface()
+		}
+	}
+	return out
+}
+
+func redactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil || u.User == nil {
+		return s
+	}
+	if _, ok := u.User.Password(); !ok {
+		return s
+	}
+	return u.Redacted()
+}
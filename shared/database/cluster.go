@@ -0,0 +1,253 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+var _ DB = (*Cluster)(nil)
+
+type primaryKey struct{}
+
+// WithPrimary marks ctx so that reads through a Cluster go to the primary,
+// e.g. to read a row right after writing it.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+func usePrimary(ctx context.Context) bool {
+	v, _ := ctx.Value(primaryKey{}).(bool)
+	return v
+}
+
+type replica struct {
+	db      *sqlx.DB
+	healthy atomic.Bool
+}
+
+// Cluster is a DB that sends reads to replicas in turn and writes and
+// transactions to the primary. Replicas that fail a health check or lag
+// behind by more than MaxLag are skipped until they recover; without a usable
+// replica reads go to the primary.
+//
+// Only plain SELECTs count as reads. Other statements, and those with
+// RETURNING or a locking clause such as FOR UPDATE, go to the primary even
+// when run through a query method. SELECTs calling functions that write must
+// use WithPrimary.
+type Cluster struct {
+	primary  *sqlx.DB
+	replicas []*replica
+	next     atomic.Uint64
+	logger   *zap.Logger
+
+	// MaxLag is the replication lag above which a replica is skipped.
+	MaxLag time.Duration
+	// CheckInterval is the time between replica health checks in Run.
+	CheckInterval time.Duration
+}
+
+func NewCluster(primary *sqlx.DB, replicas []*sqlx.DB, logger *zap.Logger) *Cluster {
+	c := &Cluster{
+		primary:       primary,
+		logger:        logger,
+		MaxLag:        10 * time.Second,
+		CheckInterval: 5 * time.Second,
+	}
+	for _, db := range replicas {
+		r := &replica{db: db}
+		r.healthy.Store(true)
+		c.replicas = append(c.replicas, r)
+	}
+	return c
+}
+
+// ConnectCluster connects to the primary and every replica with Connect.
+func ConnectCluster(ctx context.Context, primaryURL string, replicaURLs []string, opts Options, logger *zap.Logger) (*Cluster, error) {
+	primary, err := Connect(ctx, primaryURL, opts, logger)
+	if err != nil {
+		return nil, err
+	}
+	var replicas []*sqlx.DB
+	for _, u := range replicaURLs {
+		db, err := Connect(ctx, u, opts, logger)
+		if err != nil {
+			primary.Close()
+			for _, r := range replicas {
+				r.Close()
+			}
+			return nil, err
+		}
+		replicas = append(replicas, db)
+	}
+	return NewCluster(primary, replicas, logger), nil
+}
+
+func (c *Cluster) Primary() *sqlx.DB {
+	return c.primary
+}
+
+func (c *Cluster) Replicas() []*sqlx.DB {
+	dbs := make([]*sqlx.DB, len(c.replicas))
+	for i, r := range c.replicas {
+		dbs[i] = r.db
+	}
+	return dbs
+}
+
+var (
+	leadingComments = regexp.MustCompile(`^(\s+|--[^\n]*|/\*(?s:.*?)\*/)*`)
+	writeClauses    = regexp.MustCompile(`(?i)\bRETURNING\b|\bFOR\s+(NO\s+KEY\s+UPDATE|UPDATE|KEY\s+SHARE|SHARE)\b`)
+)
+
+// readOnly reports whether query is a plain SELECT that a replica can run.
+func readOnly(query string) bool {
+	query = leadingComments.ReplaceAllString(query, "")
+	if len(query) < 6 || !strings.EqualFold(query[:6], "SELECT") {
+		return false
+	}
+	return !writeClauses.MatchString(query)
+}
+
+// reader picks the database for query.
+func (c *Cluster) reader(ctx context.Context, query string) *sqlx.DB {
+	if usePrimary(ctx) || !readOnly(query) {
+		return c.primary
+	}
+	n := len(c.replicas)
+	start := c.next.Add(1)
+	for i := 0; i < n; i++ {
+		r := c.replicas[(start+uint64(i))%uint64(n)]
+		if r.healthy.Load() {
+			return r.db
+		}
+	}
+	return c.primary
+}
+
+func (c *Cluster) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return c.reader(ctx, query).QueryContext(ctx, query, args...)
+}
+
+func (c *Cluster) QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error) {
+	return c.reader(ctx, query).QueryxContext(ctx, query, args...)
+}
+
+func (c *Cluster) QueryRowxContext(ctx context.Context, query string, args ...interface{}) *sqlx.Row {
+	return c.reader(ctx, query).QueryRowxContext(ctx, query, args...)
+}
+
+func (c *Cluster) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return c.reader(ctx, query).GetContext(ctx, dest, query, args...)
+}
+
+func (c *Cluster) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return c.reader(ctx, query).SelectContext(ctx, dest, query, args...)
+}
+
+func (c *Cluster) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return c.primary.ExecContext(ctx, query, args...)
+}
+
+func (c *Cluster) NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
+	return c.primary.NamedExecContext(ctx, query, arg)
+}
+
+func (c *Cluster) BeginTxx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error) {
+	return c.primary.BeginTxx(ctx, opts)
+}
+
+func (c *Cluster) DriverName() string {
+	return c.primary.DriverName()
+}
+
+func (c *Cluster) Rebind(query string) string {
+	return c.primary.Rebind(query)
+}
+
+func (c *Cluster) BindNamed(query string, arg interface{}) (string, []interface{}, error) {
+	return c.primary.BindNamed(query, arg)
+}
+
+// PingContext pings the primary. Replicas are checked by Run.
+func (c *Cluster) PingContext(ctx context.Context) error {
+	return c.primary.PingContext(ctx)
+}
+
+func (c *Cluster) Close() error {
+	err := c.primary.Close()
+	for _, r := range c.replicas {
+		if rerr := r.db.Close(); err == nil {
+			err = rerr
+		}
+	}
+	return err
+}
+
+// Run checks the replicas every CheckInterval until ctx is cancelled.
+func (c *Cluster) Run(ctx context.Context) {
+	if len(c.replicas) == 0 {
+		return
+	}
+	ticker := time.NewTicker(c.CheckInterval)
+	defer ticker.Stop()
+	for {
+		c.CheckReplicas(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckReplicas marks each replica healthy if it answers and its replication
+// lag is within MaxLag.
+func (c *Cluster) CheckReplicas(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i, r := range c.replicas {
+		wg.Add(1)
+		go func(i int, r *replica) {
+			defer wg.Done()
+			err := c.checkReplica(ctx, r)
+			healthy := err == nil
+			if r.healthy.Swap(healthy) != healthy {
+				if healthy {
+					c.logger.Info("Replica recovered", zap.Int("replica", i))
+				} else {
+					c.logger.Warn("Replica excluded from reads", zap.Int("replica", i), zap.Error(err))
+				}
+			}
+		}(i, r)
+	}
+	wg.Wait()
+}
+
+func (c *Cluster) checkReplica(ctx context.Context, r *replica) error {
+	ctx, cancel := context.WithTimeout(ctx, c.CheckInterval)
+	defer cancel()
+
+	// An idle primary writes no WAL, so only count lag while replay is
+	// behind what was received
+	var lag float64
+	err := r.db.GetContext(ctx, &lag, `
+		SELECT CASE
+			WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+			ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+		END`)
+	if err != nil {
+		return err
+	}
+	if d := time.Duration(lag * float64(time.Second)); d > c.MaxLag {
+		return fmt.Errorf("replication lag of %s exceeds %s", d, c.MaxLag)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/MuxSphere/microkit/shared/database"
+	"github.com/MuxSphere/microkit/shared/database/databasetest"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestClusterRoutesReadsAndWrites(t *testing.T) {
+	primary, primaryMock, err := databasetest.New()
+	require.NoError(t, err)
+	replica1, mock1, err := databasetest.New()
+	require.NoError(t, err)
+	replica2, mock2, err := databasetest.New()
+	require.NoError(t, err)
+
+	c := database.NewCluster(primary, []*sqlx.DB{replica1, replica2}, zap.NewNop())
+	defer c.Close()
+	ctx := context.Background()
+
+	row := func() *sqlmock.Rows { return sqlmock.NewRows([]string{"n"}).AddRow(1) }
+	mock1.ExpectQuery(`SELECT n`).WillReturnRows(row())
+	mock2.ExpectQuery(`SELECT n`).WillReturnRows(row())
+	primaryMock.ExpectExec(`UPDATE items`).WillReturnResult(sqlmock.NewResult(0, 1))
+	primaryMock.ExpectQuery(`SELECT n`).WillReturnRows(row())
+
+	var n int
+	require.NoError(t, c.GetContext(ctx, &n, `SELECT n FROM items`))
+	require.NoError(t, c.GetContext(ctx, &n, `SELECT n FROM items`))
+	_, err = c.ExecContext(ctx, `UPDATE items SET n = 2`)
+	require.NoError(t, err)
+	require.NoError(t, c.GetContext(database.WithPrimary(ctx), &n, `SELECT n FROM items`))
+
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+	assert.NoError(t, mock1.ExpectationsWereMet())
+	assert.NoError(t, mock2.ExpectationsWereMet())
+}
+
+func TestClusterSendsWritingQueriesToPrimary(t *testing.T) {
+	primary, primaryMock, err := databasetest.New()
+	require.NoError(t, err)
+	replica, replicaMock, err := databasetest.New()
+	require.NoError(t, err)
+
+	c := database.NewCluster(primary, []*sqlx.DB{replica}, zap.NewNop())
+	defer c.Close()
+	ctx := context.Background()
+
+	row := func() *sqlmock.Rows { return sqlmock.NewRows([]string{"n"}).AddRow(1) }
+	primaryMock.ExpectQuery(`INSERT INTO items`).WillReturnRows(row())
+	primaryMock.ExpectQuery(`WITH moved AS`).WillReturnRows(row())
+	primaryMock.ExpectQuery(`SELECT n FROM items WHERE id = \$1 FOR UPDATE`).WillReturnRows(row())
+	primaryMock.ExpectQuery(`SELECT n FROM items FOR NO KEY UPDATE`).WillReturnRows(row())
+	primaryMock.ExpectQuery(`SELECT n FROM items FOR SHARE`).WillReturnRows(row())
+	replicaMock.ExpectQuery(`select n FROM items`).WillReturnRows(row())
+
+	var n int
+	require.NoError(t, c.GetContext(ctx, &n, `INSERT INTO items (n) VALUES (1) RETURNING n`))
+	require.NoError(t, c.GetContext(ctx, &n, `WITH moved AS (DELETE FROM items RETURNING n) SELECT count(*) FROM moved`))
+	require.NoError(t, c.QueryRowxContext(ctx, `SELECT n FROM items WHERE id = $1 FOR UPDATE`, 1).Scan(&n))
+	rows, err := c.QueryContext(ctx, `SELECT n FROM items FOR NO KEY UPDATE`)
+	require.NoError(t, err)
+	rows.Close()
+	var ns []int
+	require.NoError(t, c.SelectContext(ctx, &ns, `SELECT n FROM items FOR SHARE`))
+	require.NoError(t, c.GetContext(ctx, &n, `
+		-- plain reads still go to the replica
+		select n FROM items`))
+
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+	assert.NoError(t, replicaMock.ExpectationsWereMet())
+}
+
+func TestClusterSkipsUnhealthyReplicas(t *testing.T) {
+	primary, primaryMock, err := databasetest.New()
+	require.NoError(t, err)
+	failing, failingMock, err := databasetest.New()
+	require.NoError(t, err)
+	lagging, laggingMock, err := databasetest.New()
+	require.NoError(t, err)
+
+	c := database.NewCluster(primary, []*sqlx.DB{failing, lagging}, zap.NewNop())
+	defer c.Close()
+	ctx := context.Background()
+
+	failingMock.ExpectQuery(`pg_last_wal_replay_lsn`).WillReturnError(errors.New("connection refused"))
+	laggingMock.ExpectQuery(`pg_last_wal_replay_lsn`).WillReturnRows(sqlmock.NewRows([]string{"lag"}).AddRow(60.0))
+	c.CheckReplicas(ctx)
+
+	primaryMock.ExpectQuery(`SELECT n`).WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(1))
+	var n int
+	require.NoError(t, c.GetContext(ctx, &n, `SELECT n FROM items`))
+
+	// The lagging replica catches up and serves reads again.
+	failingMock.ExpectQuery(`pg_last_wal_replay_lsn`).WillReturnError(errors.New("connection refused"))
+	laggingMock.ExpectQuery(`pg_last_wal_replay_lsn`).WillReturnRows(sqlmock.NewRows([]string{"lag"}).AddRow(0.5))
+	c.CheckReplicas(ctx)
+
+	laggingMock.ExpectQuery(`SELECT n`).WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(1))
+	require.NoError(t, c.GetContext(ctx, &n, `SELECT n FROM items`))
+
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+	assert.NoError(t, failingMock.ExpectationsWereMet())
+	assert.NoError(t, laggingMock.ExpectationsWereMet())
+}
+
+func TestClusterRepositoryWritesGoToPrimary(t *testing.T) {
+	primary, primaryMock, err := databasetest.New()
+	require.NoError(t, err)
+	replica, replicaMock, err := databasetest.New()
+	require.NoError(t, err)
+
+	c := database.NewCluster(primary, []*sqlx.DB{replica}, zap.NewNop())
+	defer c.Close()
+	repo := database.NewRepository[widget](c, "widgets")
+	repo.Generated = []string{"id", "created_at"}
+	ctx := context.Background()
+
+	// INSERT and UPDATE ... RETURNING are queries, but must not reach a replica
+	primaryMock.ExpectQuery(`INSERT INTO widgets`).
+		WillReturnRows(sqlmock.NewRows(widgetColumns).AddRow(1, "gear", "active", 1, time.Now()))
+	primaryMock.ExpectQuery(`UPDATE widgets`).WillReturnRows(sqlmock.NewRows(widgetColumns))
+	primaryMock.ExpectQuery(`SELECT EXISTS`).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	replicaMock.ExpectQuery(`SELECT id, name, status, version, created_at FROM widgets`).
+		WillReturnRows(sqlmock.NewRows(widgetColumns).AddRow(1, "gear", "active", 1, time.Now()))
+
+	w := &widget{Name: "gear", Status: "active"}
+	require.NoError(t, repo.Insert(ctx, w))
+	w.Version = 0
+	assert.ErrorIs(t, repo.Update(ctx, w), database.ErrConflict)
+	_, err = repo.Get(ctx, 1)
+	require.NoError(t, err)
+
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+	assert.NoError(t, replicaMock.ExpectationsWereMet())
+}
@@ -1,22 +1,174 @@
 package database
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq" // PostgreSQL driver
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"go.uber.org/zap"
 )
 
+// Executor runs queries. It is implemented by *sqlx.DB and *sqlx.Tx, so code
+// written against it works both inside and outside a transaction.
+type Executor interface {
+	sqlx.ExtContext
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error)
+}
+
+// DB is an Executor that can also start transactions. It is implemented by
+// *sqlx.DB.
+type DB interface {
+	Executor
+	BeginTxx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error)
+	PingContext(ctx context.Context) error
+	Close() error
+}
+
+var (
+	_ DB       = (*sqlx.DB)(nil)
+	_ Executor = (*sqlx.Tx)(nil)
+)
+
+// Options configures the connection pool and how long Connect waits for the
+// database to come up.
+type Options struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// ConnectTimeout is the total time spent retrying the first connection.
+	// Zero means a single attempt.
+	ConnectTimeout time.Duration
+	// InitialBackoff is the wait after the first failed attempt. It doubles
+	// after every attempt up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+
+	// Refresh, when set, is called with the configured URL before every new
+	// connection and returns the URL to dial. It lets rotated credentials take
+	// effect without a restart; open connections keep theirs until they are
+	// closed, e.g. after ConnMaxLifetime.
+	Refresh func(ctx context.Context, url string) (string, error) `config:"-"`
+}
+
+func DefaultOptions() Options {
+	return Options{
+		MaxOpenConns:    25,
+		MaxIdleConns:    25,
+		ConnMaxLifetime: 5 * time.Minute,
+		InitialBackoff:  500 * time.Millisecond,
+		MaxBackoff:      5 * time.Second,
+	}
+}
+
 func NewConnection(databaseURL string) (*sqlx.DB, error) {
-	db, err := sqlx.Connect("postgres", databaseURL)
-	if err != nil {
+	return Connect(context.Background(), databaseURL, DefaultOptions(), zap.NewNop())
+}
+
+// Connect opens a connection pool and retries the first connection with
+// exponential backoff until it succeeds, opts.ConnectTimeout passes or ctx is
+// done.
+func Connect(ctx context.Context, databaseURL string, opts Options, logger *zap.Logger) (*sqlx.DB, error) {
+	var db *sqlx.DB
+	var err error
+	if opts.Refresh != nil {
+		db = sqlx.NewDb(sql.OpenDB(&refreshConnector{url: databaseURL, refresh: opts.Refresh}), "postgres")
+	} else if db, err = sqlx.Open("postgres", databaseURL); err != nil {
 		return nil, err
 	}
 
 	// Configure connection pool
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(25)
-	db.SetConnMaxLifetime(5 * time.Minute)
+	db.SetMaxOpenConns(opts.MaxOpenConns)
+	db.SetMaxIdleConns(opts.MaxIdleConns)
+	db.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(opts.ConnMaxIdleTime)
+
+	deadline := time.Now().Add(opts.ConnectTimeout)
+	backoff := opts.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err = db.PingContext(ctx)
+		if err == nil {
+			return db, nil
+		}
+		if time.Now().Add(backoff).After(deadline) {
+			break
+		}
+		logger.Warn("Database not ready, retrying", zap.Int("attempt", attempt), zap.Duration("backoff", backoff), zap.Error(err))
+		select {
+		case <-ctx.Done():
+			db.Close()
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > opts.MaxBackoff {
+			backoff = opts.MaxBackoff
+		}
+	}
+	db.Close()
+	return nil, err
+}
+
+// refreshConnector dials the URL returned by refresh for every connection.
+type refreshConnector struct {
+	url     string
+	refresh func(ctx context.Context, url string) (string, error)
+}
 
-	return db, nil
+func (c *refreshConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	u, err := c.refresh(ctx, c.url)
+	if err != nil {
+		return nil, err
+	}
+	connector, err := pq.NewConnector(u)
+	if err != nil {
+		return nil, err
+	}
+	return connector.Connect(ctx)
+}
+
+func (c *refreshConnector) Driver() driver.Driver {
+	return &pq.Driver{}
+}
+
+// HealthChecker reports whether the database answers.
+type HealthChecker struct {
+	db DB
+
+	// Timeout bounds each check.
+	Timeout time.Duration
+}
+
+func NewHealthChecker(db DB) *HealthChecker {
+	return &HealthChecker{db: db, Timeout: 2 * time.Second}
+}
+
+func (h *HealthChecker) Check(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, h.Timeout)
+	defer cancel()
+	return h.db.PingContext(ctx)
+}
+
+// RegisterMetrics exports the pool statistics of db, such as open, in-use and
+// idle connections and wait counts, labelled with dbName.
+func RegisterMetrics(reg prometheus.Registerer, db *sqlx.DB, dbName string) error {
+	return reg.Register(collectors.NewDBStatsCollector(db.DB, dbName))
+}
+
+// Name returns the database name in a connection URL, for use as a label.
+func Name(databaseURL string) string {
+	u, err := url.Parse(databaseURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(u.Path, "/")
 }
@@ -0,0 +1,19 @@
+// Package databasetest provides a fake database for tests that need a real
+// *sqlx.DB without a running Postgres.
+package databasetest
+
+import (
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+// New returns a *sqlx.DB backed by sqlmock. Queries are answered according to
+// the expectations set on the returned Sqlmock; set expectations with
+// sqlmock's regular expression matching in mind.
+func New() (*sqlx.DB, sqlmock.Sqlmock, error) {
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		return nil, nil, err
+	}
+	return sqlx.NewDb(conn, "postgres"), mock, nil
+}
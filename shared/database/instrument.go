@@ -0,0 +1,206 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MuxSphere/microkit/shared/logger"
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type operationKey struct{}
+
+// WithOperation names the queries made with ctx, e.g. "items.list". The name
+// labels metrics, slow query logs and spans.
+func WithOperation(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, operationKey{}, name)
+}
+
+func operation(ctx context.Context) string {
+	if name, ok := ctx.Value(operationKey{}).(string); ok {
+		return name
+	}
+	return "unnamed"
+}
+
+// Instrumentation records query latency, logs slow queries and emits spans
+// for executors wrapped with Instrument or InstrumentDB.
+type Instrumentation struct {
+	dbName   string
+	logger   *zap.Logger
+	tracer   trace.Tracer
+	duration *prometheus.HistogramVec
+
+	// SlowThreshold is the duration above which a query is logged. Zero
+	// disables the slow query log.
+	SlowThreshold time.Duration
+}
+
+// NewInstrumentation registers the db_query_duration_seconds histogram with
+// reg. Spans go to the global OpenTelemetry tracer provider.
+func NewInstrumentation(reg prometheus.Registerer, dbName string, logger *zap.Logger) (*Instrumentation, error) {
+	duration := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:        "db_query_duration_seconds",
+			Help:        "Duration of database queries",
+			ConstLabels: prometheus.Labels{"db_name": dbName},
+			Buckets:     []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		},
+		[]string{"operation", "status"},
+	)
+	if err := reg.Register(duration); err != nil {
+		return nil, err
+	}
+	return &Instrumentation{
+		dbName:        dbName,
+		logger:        logger,
+		tracer:        otel.Tracer("github.com/MuxSphere/microkit/shared/database"),
+		duration:      duration,
+		SlowThreshold: 200 * time.Millisecond,
+	}, nil
+}
+
+// Instrument wraps exec, which may be a *sqlx.DB or a *sqlx.Tx.
+func (i *Instrumentation) Instrument(exec Executor) Executor {
+	return &instrumented{Executor: exec, ins: i}
+}
+
+// InstrumentDB wraps db. Transactions found by ExecutorFrom are instrumented
+// too.
+func (i *Instrumentation) InstrumentDB(db DB) DB {
+	return &instrumentedDB{instrumented: instrumented{Executor: db, ins: i}, db: db}
+}
+
+// observe starts a span for query and returns a function that ends it and
+// records the outcome.
+func (i *Instrumentation) observe(ctx context.Context, query string, args []interface{}) (context.Context, func(error)) {
+	op := operation(ctx)
+	ctx, span := i.tracer.Start(ctx, "db "+op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.name", i.dbName),
+			attribute.String("db.operation", op),
+			attribute.String("db.statement", query),
+		))
+	start := time.Now()
+
+	return ctx, func(err error) {
+		elapsed := time.Since(start)
+		status := "ok"
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			status = "error"
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+		i.duration.WithLabelValues(op, status).Observe(elapsed.Seconds())
+
+		// Slow queries are always logged, the rest only for requests logged at
+		// debug level
+		l := logger.FromContext(ctx, i.logger)
+		ce := l.Check(zap.DebugLevel, "Query")
+		if i.SlowThreshold > 0 && elapsed >= i.SlowThreshold {
+			ce = l.Check(zap.WarnLevel, "Slow query")
+		}
+		if ce != nil {
+			ce.Write(
+				zap.String("operation", op),
+				zap.Duration("duration", elapsed),
+				zap.String("query", query),
+				zap.Strings("args", redact(args)),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// redact replaces argument values with their types, so logs never contain
+// user data.
+func redact(args []interface{}) []string {
+	redacted := make([]string, len(args))
+	for n, arg := range args {
+		redacted[n] = fmt.Sprintf("$%d=%T", n+1, arg)
+	}
+	return redacted
+}
+
+type instrumented struct {
+	Executor
+	ins *Instrumentation
+}
+
+func (e *instrumented) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, done := e.ins.observe(ctx, query, args)
+	res, err := e.Executor.ExecContext(ctx, query, args...)
+	done(err)
+	return res, err
+}
+
+func (e *instrumented) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, done := e.ins.observe(ctx, query, args)
+	rows, err := e.Executor.QueryContext(ctx, query, args...)
+	done(err)
+	return rows, err
+}
+
+func (e *instrumented) QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error) {
+	ctx, done := e.ins.observe(ctx, query, args)
+	rows, err := e.Executor.QueryxContext(ctx, query, args...)
+	done(err)
+	return rows, err
+}
+
+func (e *instrumented) QueryRowxContext(ctx context.Context, query string, args ...interface{}) *sqlx.Row {
+	ctx, done := e.ins.observe(ctx, query, args)
+	row := e.Executor.QueryRowxContext(ctx, query, args...)
+	done(row.Err())
+	return row
+}
+
+func (e *instrumented) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	ctx, done := e.ins.observe(ctx, query, args)
+	err := e.Executor.GetContext(ctx, dest, query, args...)
+	done(err)
+	return err
+}
+
+func (e *instrumented) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	ctx, done := e.ins.observe(ctx, query, args)
+	err := e.Executor.SelectContext(ctx, dest, query, args...)
+	done(err)
+	return err
+}
+
+func (e *instrumented) NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
+	ctx, done := e.ins.observe(ctx, query, []interface{}{arg})
+	res, err := e.Executor.NamedExecContext(ctx, query, arg)
+	done(err)
+	return res, err
+}
+
+type instrumentedDB struct {
+	instrumented
+	db DB
+}
+
+func (d *instrumentedDB) BeginTxx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error) {
+	return d.db.BeginTxx(ctx, opts)
+}
+
+func (d *instrumentedDB) PingContext(ctx context.Context) error {
+	return d.db.PingContext(ctx)
+}
+
+func (d *instrumentedDB) Close() error {
+	return d.db.Close()
+}
@@ -0,0 +1,132 @@
+package database_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/MuxSphere/microkit/shared/database"
+	"github.com/MuxSphere/microkit/shared/database/databasetest"
+	"github.com/MuxSphere/microkit/shared/logger"
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func queryCounts(t *testing.T, reg *prometheus.Registry) map[string]uint64 {
+	t.Helper()
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	counts := map[string]uint64{}
+	for _, f := range families {
+		if f.GetName() != "db_query_duration_seconds" {
+			continue
+		}
+		for _, m := range f.Metric {
+			labels := map[string]string{}
+			for _, l := range m.Label {
+				labels[l.GetName()] = l.GetValue()
+			}
+			counts[labels["operation"]+"/"+labels["status"]] = m.GetHistogram().GetSampleCount()
+		}
+	}
+	return counts
+}
+
+func TestInstrumentationRecordsQueries(t *testing.T) {
+	sqlDB, mock, err := databasetest.New()
+	require.NoError(t, err)
+	reg := prometheus.NewRegistry()
+	ins, err := database.NewInstrumentation(reg, "test", zap.NewNop())
+	require.NoError(t, err)
+	db := ins.InstrumentDB(sqlDB)
+
+	mock.ExpectQuery(`SELECT name FROM items`).WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("a"))
+	mock.ExpectQuery(`SELECT name FROM items`).WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec(`DELETE FROM items`).WillReturnError(errors.New("boom"))
+
+	ctx := database.WithOperation(context.Background(), "items.get")
+	var name string
+	require.NoError(t, db.GetContext(ctx, &name, `SELECT name FROM items WHERE id = $1`, 1))
+	assert.ErrorIs(t, db.GetContext(ctx, &name, `SELECT name FROM items WHERE id = $1`, 2), sql.ErrNoRows)
+	_, err = db.ExecContext(context.Background(), `DELETE FROM items`)
+	assert.Error(t, err)
+
+	// sql.ErrNoRows is not a failure, so both lookups share a series
+	assert.Equal(t, map[string]uint64{"items.get/ok": 2, "unnamed/error": 1}, queryCounts(t, reg))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInstrumentationLogsSlowQueries(t *testing.T) {
+	sqlDB, mock, err := databasetest.New()
+	require.NoError(t, err)
+	core, logs := observer.New(zap.WarnLevel)
+	ins, err := database.NewInstrumentation(prometheus.NewRegistry(), "test", zap.New(core))
+	require.NoError(t, err)
+	ins.SlowThreshold = 10 * time.Millisecond
+	db := ins.Instrument(sqlDB)
+
+	mock.ExpectExec(`UPDATE items`).WithArgs("secret", 1).WillDelayFor(20 * time.Millisecond).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE items`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ctx := database.WithOperation(context.Background(), "items.rename")
+	_, err = db.ExecContext(ctx, `UPDATE items SET name = $1 WHERE id = $2`, "secret", 1)
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, `UPDATE items SET name = 'x'`)
+	require.NoError(t, err)
+
+	require.Equal(t, 1, logs.Len())
+	fields := logs.All()[0].ContextMap()
+	assert.Equal(t, "items.rename", fields["operation"])
+	assert.Equal(t, []interface{}{"$1=string", "$2=int"}, fields["args"])
+	assert.NotContains(t, fields["args"], "secret")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInstrumentationLogsQueriesOfDebugRequests(t *testing.T) {
+	sqlDB, mock, err := databasetest.New()
+	require.NoError(t, err)
+	ins, err := database.NewInstrumentation(prometheus.NewRegistry(), "test", zap.NewNop())
+	require.NoError(t, err)
+	db := ins.Instrument(sqlDB)
+
+	mock.ExpectExec(`DELETE FROM items`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	core, logs := observer.New(zap.DebugLevel)
+	ctx := logger.WithContext(database.WithOperation(context.Background(), "items.delete"), zap.New(core))
+	_, err = db.ExecContext(ctx, `DELETE FROM items WHERE id = $1`, 1)
+	require.NoError(t, err)
+
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, "Query", logs.All()[0].Message)
+	assert.Equal(t, "items.delete", logs.All()[0].ContextMap()["operation"])
+}
+
+func TestExecutorFromInstrumentsTransactions(t *testing.T) {
+	sqlDB, mock, err := databasetest.New()
+	require.NoError(t, err)
+	reg := prometheus.NewRegistry()
+	ins, err := database.NewInstrumentation(reg, "test", zap.NewNop())
+	require.NoError(t, err)
+	db := ins.InstrumentDB(sqlDB)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO items`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	ctx := database.WithOperation(context.Background(), "items.create")
+	err = database.WithTx(ctx, db, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+		_, err := database.ExecutorFrom(ctx, db).ExecContext(ctx, `INSERT INTO items (name) VALUES ($1)`, "a")
+		return err
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]uint64{"items.create/ok": 1}, queryCounts(t, reg))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+const migrateUsage = `usage: migrate [-dry-run] <command>
+
+commands:
+  up        apply all pending migrations
+  down [n]  revert the last n migrations (default 1)
+  status    list migrations and when they were applied
+`
+
+// RunMigrateCommand implements the migrate subcommand of a service binary.
+// args are the arguments after "migrate".
+func RunMigrateCommand(ctx context.Context, m *Migrator, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	flags.SetOutput(out)
+	flags.Usage = func() { fmt.Fprint(out, migrateUsage) }
+	dryRun := flags.Bool("dry-run", false, "run migrations and roll them back")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	m.DryRun = *dryRun
+
+	switch flags.Arg(0) {
+	case "up":
+		applied, err := m.Up(ctx)
+		if err != nil {
+			return err
+		}
+		printMigrations(out, "applied", applied, m.DryRun, "no pending migrations")
+	case "down":
+		steps := 1
+		if n := flags.Arg(1); n != "" {
+			var err error
+			if steps, err = strconv.Atoi(n); err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps %q", n)
+			}
+		}
+		reverted, err := m.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		printMigrations(out, "reverted", reverted, m.DryRun, "no applied migrations")
+	case "status":
+		status, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+		for _, s := range status {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+		return w.Flush()
+	default:
+		flags.Usage()
+		return fmt.Errorf("unknown migrate command %q", flags.Arg(0))
+	}
+	return nil
+}
+
+func printMigrations(out io.Writer, verb string, migrations []Migration, dryRun bool, none string) {
+	if len(migrations) == 0 {
+		fmt.Fprintln(out, none)
+		return
+	}
+	if dryRun {
+		verb = "would have " + verb
+	}
+	for _, m := range migrations {
+		fmt.Fprintf(out, "%s %d_%s\n", verb, m.Version, m.Name)
+	}
+}
@@ -0,0 +1,105 @@
+package database_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/MuxSphere/microkit/shared/database"
+	"github.com/MuxSphere/microkit/shared/database/databasetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var testMigrations = fstest.MapFS{
+	"migrations/0002_add_index.up.sql":      {Data: []byte("CREATE INDEX items_name_idx ON items (name);")},
+	"migrations/0001_create_items.up.sql":   {Data: []byte("CREATE TABLE items (id BIGSERIAL PRIMARY KEY, name TEXT);")},
+	"migrations/0001_create_items.down.sql": {Data: []byte("DROP TABLE items;")},
+	"migrations/README.md":                  {Data: []byte("ignored")},
+}
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := database.LoadMigrations(testMigrations, "migrations")
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+	assert.Equal(t, int64(1), migrations[0].Version)
+	assert.Equal(t, "create_items", migrations[0].Name)
+	assert.Equal(t, "DROP TABLE items;", migrations[0].Down)
+	assert.Equal(t, "add_index", migrations[1].Name)
+	assert.Empty(t, migrations[1].Down)
+
+	_, err = database.LoadMigrations(fstest.MapFS{"m/x_create.up.sql": {}}, "m")
+	assert.Error(t, err)
+	_, err = database.LoadMigrations(fstest.MapFS{"m/0001_create.down.sql": {Data: []byte("DROP")}}, "m")
+	assert.Error(t, err)
+	_, err = database.LoadMigrations(fstest.MapFS{
+		"m/0001_a.up.sql": {Data: []byte("A")},
+		"m/0001_b.up.sql": {Data: []byte("B")},
+	}, "m")
+	assert.Error(t, err)
+}
+
+func expectRun(mock sqlmock.Sqlmock, applied ...int64) {
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS schema_migrations`).WillReturnResult(sqlmock.NewResult(0, 0))
+	rows := sqlmock.NewRows([]string{"version", "applied_at"})
+	for _, v := range applied {
+		rows.AddRow(v, time.Now())
+	}
+	mock.ExpectQuery(`SELECT version, applied_at FROM schema_migrations`).WillReturnRows(rows)
+}
+
+func TestMigratorUp(t *testing.T) {
+	db, mock, err := databasetest.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	migrations, err := database.LoadMigrations(testMigrations, "migrations")
+	require.NoError(t, err)
+	m := database.NewMigrator(db, migrations, zap.NewNop())
+
+	expectRun(mock, 1)
+	mock.ExpectExec(`CREATE INDEX items_name_idx`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO schema_migrations`).WithArgs(int64(2), "add_index").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	applied, err := m.Up(context.Background())
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+	assert.Equal(t, int64(2), applied[0].Version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrateCommandDryRun(t *testing.T) {
+	db, mock, err := databasetest.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	migrations, err := database.LoadMigrations(testMigrations, "migrations")
+	require.NoError(t, err)
+	m := database.NewMigrator(db, migrations, zap.NewNop())
+
+	expectRun(mock, 1)
+	mock.ExpectExec(`DROP TABLE items`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM schema_migrations`).WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	var out bytes.Buffer
+	require.NoError(t, database.RunMigrateCommand(context.Background(), m, []string{"-dry-run", "down"}, &out))
+	assert.Equal(t, "would have reverted 1_create_items\n", out.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	expectRun(mock, 1)
+	mock.ExpectCommit()
+	out.Reset()
+	require.NoError(t, database.RunMigrateCommand(context.Background(), m, []string{"status"}, &out))
+	assert.Contains(t, out.String(), "add_index")
+	assert.Contains(t, out.String(), "pending")
+
+	assert.Error(t, database.RunMigrateCommand(context.Background(), m, []string{"sideways"}, &out))
+}
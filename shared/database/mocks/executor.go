@@ -0,0 +1,645 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	sql "database/sql"
+
+	sqlx "github.com/jmoiron/sqlx"
+)
+
+// Executor is an autogenerated mock type for the Executor type
+type Executor struct {
+	mock.Mock
+}
+
+type Executor_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *Executor) EXPECT() *Executor_Expecter {
+	return &Executor_Expecter{mock: &_m.Mock}
+}
+
+// BindNamed provides a mock function with given fields: _a0, _a1
+func (_m *Executor) BindNamed(_a0 string, _a1 interface{}) (string, []interface{}, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BindNamed")
+	}
+
+	var r0 string
+	var r1 []interface{}
+	var r2 error
+	if rf, ok := ret.Get(0).(func(string, interface{}) (string, []interface{}, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(string, interface{}) string); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, interface{}) []interface{}); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]interface{})
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(string, interface{}) error); ok {
+		r2 = rf(_a0, _a1)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Executor_BindNamed_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'BindNamed'
+type Executor_BindNamed_Call struct {
+	*mock.Call
+}
+
+// BindNamed is a helper method to define mock.On call
+//   - _a0 string
+//   - _a1 interface{}
+func (_e *Executor_Expecter) BindNamed(_a0 interface{}, _a1 interface{}) *Executor_BindNamed_Call {
+	return &Executor_BindNamed_Call{Call: _e.mock.On("BindNamed", _a0, _a1)}
+}
+
+func (_c *Executor_BindNamed_Call) Run(run func(_a0 string, _a1 interface{})) *Executor_BindNamed_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(interface{}))
+	})
+	return _c
+}
+
+func (_c *Executor_BindNamed_Call) Return(_a0 string, _a1 []interface{}, _a2 error) *Executor_BindNamed_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *Executor_BindNamed_Call) RunAndReturn(run func(string, interface{}) (string, []interface{}, error)) *Executor_BindNamed_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DriverName provides a mock function with no fields
+func (_m *Executor) DriverName() string {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for DriverName")
+	}
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// Executor_DriverName_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DriverName'
+type Executor_DriverName_Call struct {
+	*mock.Call
+}
+
+// DriverName is a helper method to define mock.On call
+func (_e *Executor_Expecter) DriverName() *Executor_DriverName_Call {
+	return &Executor_DriverName_Call{Call: _e.mock.On("DriverName")}
+}
+
+func (_c *Executor_DriverName_Call) Run(run func()) *Executor_DriverName_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Executor_DriverName_Call) Return(_a0 string) *Executor_DriverName_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Executor_DriverName_Call) RunAndReturn(run func() string) *Executor_DriverName_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ExecContext provides a mock function with given fields: ctx, query, args
+func (_m *Executor) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	var _ca []interface{}
+	_ca = append(_ca, ctx, query)
+	_ca = append(_ca, args...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExecContext")
+	}
+
+	var r0 sql.Result
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...interface{}) (sql.Result, error)); ok {
+		return rf(ctx, query, args...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...interface{}) sql.Result); ok {
+		r0 = rf(ctx, query, args...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(sql.Result)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, ...interface{}) error); ok {
+		r1 = rf(ctx, query, args...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Executor_ExecContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ExecContext'
+type Executor_ExecContext_Call struct {
+	*mock.Call
+}
+
+// ExecContext is a helper method to define mock.On call
+//   - ctx context.Context
+//   - query string
+//   - args ...interface{}
+func (_e *Executor_Expecter) ExecContext(ctx interface{}, query interface{}, args ...interface{}) *Executor_ExecContext_Call {
+	return &Executor_ExecContext_Call{Call: _e.mock.On("ExecContext",
+		append([]interface{}{ctx, query}, args...)...)}
+}
+
+func (_c *Executor_ExecContext_Call) Run(run func(ctx context.Context, query string, args ...interface{})) *Executor_ExecContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]interface{}, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(interface{})
+			}
+		}
+		run(args[0].(context.Context), args[1].(string), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *Executor_ExecContext_Call) Return(_a0 sql.Result, _a1 error) *Executor_ExecContext_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Executor_ExecContext_Call) RunAndReturn(run func(context.Context, string, ...interface{}) (sql.Result, error)) *Executor_ExecContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetContext provides a mock function with given fields: ctx, dest, query, args
+func (_m *Executor) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	var _ca []interface{}
+	_ca = append(_ca, ctx, dest, query)
+	_ca = append(_ca, args...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetContext")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, interface{}, string, ...interface{}) error); ok {
+		r0 = rf(ctx, dest, query, args...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Executor_GetContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetContext'
+type Executor_GetContext_Call struct {
+	*mock.Call
+}
+
+// GetContext is a helper method to define mock.On call
+//   - ctx context.Context
+//   - dest interface{}
+//   - query string
+//   - args ...interface{}
+func (_e *Executor_Expecter) GetContext(ctx interface{}, dest interface{}, query interface{}, args ...interface{}) *Executor_GetContext_Call {
+	return &Executor_GetContext_Call{Call: _e.mock.On("GetContext",
+		append([]interface{}{ctx, dest, query}, args...)...)}
+}
+
+func (_c *Executor_GetContext_Call) Run(run func(ctx context.Context, dest interface{}, query string, args ...interface{})) *Executor_GetContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]interface{}, len(args)-3)
+		for i, a := range args[3:] {
+			if a != nil {
+				variadicArgs[i] = a.(interface{})
+			}
+		}
+		run(args[0].(context.Context), args[1].(interface{}), args[2].(string), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *Executor_GetContext_Call) Return(_a0 error) *Executor_GetContext_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Executor_GetContext_Call) RunAndReturn(run func(context.Context, interface{}, string, ...interface{}) error) *Executor_GetContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NamedExecContext provides a mock function with given fields: ctx, query, arg
+func (_m *Executor) NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
+	ret := _m.Called(ctx, query, arg)
+
+	if len(ret) == 0 {
+		panic("no return value specified for NamedExecContext")
+	}
+
+	var r0 sql.Result
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, interface{}) (sql.Result, error)); ok {
+		return rf(ctx, query, arg)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, interface{}) sql.Result); ok {
+		r0 = rf(ctx, query, arg)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(sql.Result)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, interface{}) error); ok {
+		r1 = rf(ctx, query, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Executor_NamedExecContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'NamedExecContext'
+type Executor_NamedExecContext_Call struct {
+	*mock.Call
+}
+
+// NamedExecContext is a helper method to define mock.On call
+//   - ctx context.Context
+//   - query string
+//   - arg interface{}
+func (_e *Executor_Expecter) NamedExecContext(ctx interface{}, query interface{}, arg interface{}) *Executor_NamedExecContext_Call {
+	return &Executor_NamedExecContext_Call{Call: _e.mock.On("NamedExecContext", ctx, query, arg)}
+}
+
+func (_c *Executor_NamedExecContext_Call) Run(run func(ctx context.Context, query string, arg interface{})) *Executor_NamedExecContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(interface{}))
+	})
+	return _c
+}
+
+func (_c *Executor_NamedExecContext_Call) Return(_a0 sql.Result, _a1 error) *Executor_NamedExecContext_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Executor_NamedExecContext_Call) RunAndReturn(run func(context.Context, string, interface{}) (sql.Result, error)) *Executor_NamedExecContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// QueryContext provides a mock function with given fields: ctx, query, args
+func (_m *Executor) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	var _ca []interface{}
+	_ca = append(_ca, ctx, query)
+	_ca = append(_ca, args...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for QueryContext")
+	}
+
+	var r0 *sql.Rows
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...interface{}) (*sql.Rows, error)); ok {
+		return rf(ctx, query, args...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...interface{}) *sql.Rows); ok {
+		r0 = rf(ctx, query, args...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*sql.Rows)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, ...interface{}) error); ok {
+		r1 = rf(ctx, query, args...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Executor_QueryContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'QueryContext'
+type Executor_QueryContext_Call struct {
+	*mock.Call
+}
+
+// QueryContext is a helper method to define mock.On call
+//   - ctx context.Context
+//   - query string
+//   - args ...interface{}
+func (_e *Executor_Expecter) QueryContext(ctx interface{}, query interface{}, args ...interface{}) *Executor_QueryContext_Call {
+	return &Executor_QueryContext_Call{Call: _e.mock.On("QueryContext",
+		append([]interface{}{ctx, query}, args...)...)}
+}
+
+func (_c *Executor_QueryContext_Call) Run(run func(ctx context.Context, query string, args ...interface{})) *Executor_QueryContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]interface{}, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(interface{})
+			}
+		}
+		run(args[0].(context.Context), args[1].(string), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *Executor_QueryContext_Call) Return(_a0 *sql.Rows, _a1 error) *Executor_QueryContext_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Executor_QueryContext_Call) RunAndReturn(run func(context.Context, string, ...interface{}) (*sql.Rows, error)) *Executor_QueryContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// QueryRowxContext provides a mock function with given fields: ctx, query, args
+func (_m *Executor) QueryRowxContext(ctx context.Context, query string, args ...interface{}) *sqlx.Row {
+	var _ca []interface{}
+	_ca = append(_ca, ctx, query)
+	_ca = append(_ca, args...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for QueryRowxContext")
+	}
+
+	var r0 *sqlx.Row
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...interface{}) *sqlx.Row); ok {
+		r0 = rf(ctx, query, args...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*sqlx.Row)
+		}
+	}
+
+	return r0
+}
+
+// Executor_QueryRowxContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'QueryRowxContext'
+type Executor_QueryRowxContext_Call struct {
+	*mock.Call
+}
+
+// QueryRowxContext is a helper method to define mock.On call
+//   - ctx context.Context
+//   - query string
+//   - args ...interface{}
+func (_e *Executor_Expecter) QueryRowxContext(ctx interface{}, query interface{}, args ...interface{}) *Executor_QueryRowxContext_Call {
+	return &Executor_QueryRowxContext_Call{Call: _e.mock.On("QueryRowxContext",
+		append([]interface{}{ctx, query}, args...)...)}
+}
+
+func (_c *Executor_QueryRowxContext_Call) Run(run func(ctx context.Context, query string, args ...interface{})) *Executor_QueryRowxContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]interface{}, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(interface{})
+			}
+		}
+		run(args[0].(context.Context), args[1].(string), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *Executor_QueryRowxContext_Call) Return(_a0 *sqlx.Row) *Executor_QueryRowxContext_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Executor_QueryRowxContext_Call) RunAndReturn(run func(context.Context, string, ...interface{}) *sqlx.Row) *Executor_QueryRowxContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// QueryxContext provides a mock function with given fields: ctx, query, args
+func (_m *Executor) QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error) {
+	var _ca []interface{}
+	_ca = append(_ca, ctx, query)
+	_ca = append(_ca, args...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for QueryxContext")
+	}
+
+	var r0 *sqlx.Rows
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...interface{}) (*sqlx.Rows, error)); ok {
+		return rf(ctx, query, args...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...interface{}) *sqlx.Rows); ok {
+		r0 = rf(ctx, query, args...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*sqlx.Rows)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, ...interface{}) error); ok {
+		r1 = rf(ctx, query, args...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Executor_QueryxContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'QueryxContext'
+type Executor_QueryxContext_Call struct {
+	*mock.Call
+}
+
+// QueryxContext is a helper method to define mock.On call
+//   - ctx context.Context
+//   - query string
+//   - args ...interface{}
+func (_e *Executor_Expecter) QueryxContext(ctx interface{}, query interface{}, args ...interface{}) *Executor_QueryxContext_Call {
+	return &Executor_QueryxContext_Call{Call: _e.mock.On("QueryxContext",
+		append([]interface{}{ctx, query}, args...)...)}
+}
+
+func (_c *Executor_QueryxContext_Call) Run(run func(ctx context.Context, query string, args ...interface{})) *Executor_QueryxContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]interface{}, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(interface{})
+			}
+		}
+		run(args[0].(context.Context), args[1].(string), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *Executor_QueryxContext_Call) Return(_a0 *sqlx.Rows, _a1 error) *Executor_QueryxContext_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Executor_QueryxContext_Call) RunAndReturn(run func(context.Context, string, ...interface{}) (*sqlx.Rows, error)) *Executor_QueryxContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Rebind provides a mock function with given fields: _a0
+func (_m *Executor) Rebind(_a0 string) string {
+	ret := _m.Called(_a0)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Rebind")
+	}
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// Executor_Rebind_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Rebind'
+type Executor_Rebind_Call struct {
+	*mock.Call
+}
+
+// Rebind is a helper method to define mock.On call
+//   - _a0 string
+func (_e *Executor_Expecter) Rebind(_a0 interface{}) *Executor_Rebind_Call {
+	return &Executor_Rebind_Call{Call: _e.mock.On("Rebind", _a0)}
+}
+
+func (_c *Executor_Rebind_Call) Run(run func(_a0 string)) *Executor_Rebind_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *Executor_Rebind_Call) Return(_a0 string) *Executor_Rebind_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Executor_Rebind_Call) RunAndReturn(run func(string) string) *Executor_Rebind_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SelectContext provides a mock function with given fields: ctx, dest, query, args
+func (_m *Executor) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	var _ca []interface{}
+	_ca = append(_ca, ctx, dest, query)
+	_ca = append(_ca, args...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SelectContext")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, interface{}, string, ...interface{}) error); ok {
+		r0 = rf(ctx, dest, query, args...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Executor_SelectContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SelectContext'
+type Executor_SelectContext_Call struct {
+	*mock.Call
+}
+
+// SelectContext is a helper method to define mock.On call
+//   - ctx context.Context
+//   - dest interface{}
+//   - query string
+//   - args ...interface{}
+func (_e *Executor_Expecter) SelectContext(ctx interface{}, dest interface{}, query interface{}, args ...interface{}) *Executor_SelectContext_Call {
+	return &Executor_SelectContext_Call{Call: _e.mock.On("SelectContext",
+		append([]interface{}{ctx, dest, query}, args...)...)}
+}
+
+func (_c *Executor_SelectContext_Call) Run(run func(ctx context.Context, dest interface{}, query string, args ...interface{})) *Executor_SelectContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]interface{}, len(args)-3)
+		for i, a := range args[3:] {
+			if a != nil {
+				variadicArgs[i] = a.(interface{})
+			}
+		}
+		run(args[0].(context.Context), args[1].(interface{}), args[2].(string), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *Executor_SelectContext_Call) Return(_a0 error) *Executor_SelectContext_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Executor_SelectContext_Call) RunAndReturn(run func(context.Context, interface{}, string, ...interface{}) error) *Executor_SelectContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewExecutor creates a new instance of Executor. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewExecutor(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Executor {
+	mock := &Executor{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,107 @@
+package database
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidSort   = errors.New("invalid sort")
+	ErrInvalidFilter = errors.New("invalid filter")
+	ErrInvalidLimit  = errors.New("invalid limit")
+)
+
+// ListOptions select one page of a Repository listing.
+type ListOptions struct {
+	// Limit is the page size. Zero uses the repository's DefaultLimit.
+	Limit int
+	// Cursor is the NextCursor of the previous page, empty for the first page.
+	Cursor string
+	// Sort is the column to order by, empty for the repository's DefaultSort.
+	Sort string
+	Desc bool
+	// Filters are column = value conditions.
+	Filters map[string]string
+}
+
+// Page is one page of a listing. NextCursor is empty on the last page.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// ParseListOptions reads ListOptions from the query string:
+//
+//	?limit=20&cursor=...&sort=-created_at&filter[status]=active
+//
+// A leading "-" on sort orders descending. Columns are checked by the
+// repository, so unknown ones fail there.
+func ParseListOptions(c *gin.Context) (ListOptions, error) {
+	opts := ListOptions{
+		Cursor:  c.Query("cursor"),
+		Filters: c.QueryMap("filter"),
+	}
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 0 {
+			return ListOptions{}, fmt.Errorf("%w: %s", ErrInvalidLimit, limit)
+		}
+		opts.Limit = n
+	}
+	opts.Sort, opts.Desc = ParseSort(c.Query("sort"))
+	return opts, nil
+}
+
+// ParseSort splits a sort parameter such as "-created_at" into the column and
+// whether to order descending.
+func ParseSort(sort string) (column string, desc bool) {
+	return strings.TrimPrefix(sort, "-"), strings.HasPrefix(sort, "-")
+}
+
+// IsInvalidListOptions reports whether err was caused by bad ListOptions, so
+// handlers can answer 400 instead of 500.
+func IsInvalidListOptions(err error) bool {
+	return errors.Is(err, ErrInvalidCursor) || errors.Is(err, ErrInvalidSort) ||
+		errors.Is(err, ErrInvalidFilter) || errors.Is(err, ErrInvalidLimit)
+}
+
+// cursor is the position after the last row of a page. It also records the
+// ordering, so it cannot be reused with a different sort.
+type cursor struct {
+	Sort  string      `json:"s"`
+	Desc  bool        `json:"d,omitempty"`
+	Value interface{} `json:"v,omitempty"`
+	Key   interface{} `json:"k"`
+}
+
+func (c cursor) encode() (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(token, sort string, desc bool) (cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor{}, ErrInvalidCursor
+	}
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.UseNumber()
+	var c cursor
+	if err := dec.Decode(&c); err != nil || c.Key == nil {
+		return cursor{}, ErrInvalidCursor
+	}
+	if c.Sort != sort || c.Desc != desc {
+		return cursor{}, fmt.Errorf("%w: cursor was issued for a different sort", ErrInvalidCursor)
+	}
+	return c, nil
+}
@@ -0,0 +1,287 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+var (
+	ErrNotFound = errors.New("record not found")
+	// ErrConflict is returned by Update when the row was changed since it was
+	// read, i.e. its version no longer matches.
+	ErrConflict = errors.New("record was modified concurrently")
+)
+
+// mapper matches sqlx's default field mapping.
+var mapper = reflectx.NewMapperFunc("db", sqlx.NameMapper)
+
+// Repository provides CRUD and keyset pagination over one table, scanning
+// rows into T through its db tags. Queries join the transaction in ctx, if
+// any.
+type Repository[T any] struct {
+	db      DB
+	table   string
+	columns []string
+
+	// Key is the primary key column.
+	Key string
+	// Version is the column used for optimistic locking. It is set when T has
+	// a "version" column; empty disables locking.
+	Version string
+	// Generated columns are filled in by the database, e.g. serial IDs or
+	// created_at defaults, and never written.
+	Generated []string
+	// Sortable and Filterable list the columns List accepts from ListOptions.
+	// The key is always sortable.
+	Sortable   []string
+	Filterable []string
+	// DefaultSort is the column List orders by when none is given.
+	DefaultSort  string
+	DefaultLimit int
+	MaxLimit     int
+}
+
+// NewRepository maps T onto table. T must be a struct with db tags.
+func NewRepository[T any](db DB, table string) *Repository[T] {
+	r := &Repository[T]{
+		db:           db,
+		table:        table,
+		columns:      columnsOf(reflect.TypeOf((*T)(nil)).Elem()),
+		Key:          "id",
+		DefaultSort:  "id",
+		DefaultLimit: 20,
+		MaxLimit:     100,
+	}
+	if contains(r.columns, "version") {
+		r.Version = "version"
+	}
+	return r
+}
+
+func columnsOf(t reflect.Type) []string {
+	var columns []string
+	for _, fi := range mapper.TypeMap(t).Index {
+		if fi.Embedded || strings.Contains(fi.Path, ".") {
+			continue
+		}
+		columns = append(columns, fi.Path)
+	}
+	return columns
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Repository[T]) exec(ctx context.Context, op string) (context.Context, Executor) {
+	if _, ok := ctx.Value(operationKey{}).(string); !ok {
+		ctx = WithOperation(ctx, r.table+"."+op)
+	}
+	return ctx, ExecutorFrom(ctx, r.db)
+}
+
+func (r *Repository[T]) selectList() string {
+	return strings.Join(r.columns, ", ")
+}
+
+// field returns the value of column in item.
+func (r *Repository[T]) field(item *T, column string) interface{} {
+	return mapper.FieldByName(reflect.ValueOf(item).Elem(), column).Interface()
+}
+
+// Get returns the row with the given key, or ErrNotFound.
+func (r *Repository[T]) Get(ctx context.Context, id interface{}) (*T, error) {
+	ctx, db := r.exec(ctx, "get")
+	var item T
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE %s = $1`, r.selectList(), r.table, r.Key)
+	if err := db.GetContext(ctx, &item, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+// List returns one page of rows ordered by opts.Sort and then the key, so
+// sortable columns should be NOT NULL.
+func (r *Repository[T]) List(ctx context.Context, opts ListOptions) (*Page[T], error) {
+	ctx, db := r.exec(ctx, "list")
+
+	sortBy := opts.Sort
+	if sortBy == "" {
+		sortBy = r.DefaultSort
+	}
+	if sortBy != r.Key && !contains(r.Sortable, sortBy) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSort, sortBy)
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = r.DefaultLimit
+	}
+	if limit > r.MaxLimit {
+		limit = r.MaxLimit
+	}
+
+	var where []string
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	// Sorted for stable SQL text
+	filters := make([]string, 0, len(opts.Filters))
+	for column := range opts.Filters {
+		if !contains(r.Filterable, column) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidFilter, column)
+		}
+		filters = append(filters, column)
+	}
+	sort.Strings(filters)
+	for _, column := range filters {
+		where = append(where, column+" = "+arg(opts.Filters[column]))
+	}
+
+	op, dir := ">", "ASC"
+	if opts.Desc {
+		op, dir = "<", "DESC"
+	}
+	if opts.Cursor != "" {
+		c, err := decodeCursor(opts.Cursor, sortBy, opts.Desc)
+		if err != nil {
+			return nil, err
+		}
+		if sortBy == r.Key {
+			where = append(where, fmt.Sprintf("%s %s %s", r.Key, op, arg(c.Key)))
+		} else {
+			where = append(where, fmt.Sprintf("(%s, %s) %s (%s, %s)", sortBy, r.Key, op, arg(c.Value), arg(c.Key)))
+		}
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM %s`, r.selectList(), r.table)
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	order := fmt.Sprintf("%s %s", r.Key, dir)
+	if sortBy != r.Key {
+		order = fmt.Sprintf("%s %s, %s", sortBy, dir, order)
+	}
+	// One extra row tells whether there is a next page
+	query += fmt.Sprintf(" ORDER BY %s LIMIT %s", order, arg(limit+1))
+
+	page := &Page[T]{Items: []T{}}
+	if err := db.SelectContext(ctx, &page.Items, query, args...); err != nil {
+		return nil, err
+	}
+	if len(page.Items) > limit {
+		page.Items = page.Items[:limit]
+		last := &page.Items[limit-1]
+		c := cursor{Sort: sortBy, Desc: opts.Desc, Key: r.field(last, r.Key)}
+		if sortBy != r.Key {
+			c.Value = r.field(last, sortBy)
+		}
+		next, err := c.encode()
+		if err != nil {
+			return nil, err
+		}
+		page.NextCursor = next
+	}
+	return page, nil
+}
+
+// Insert writes item and updates it with the stored row, including generated
+// columns. The version starts at 1. Through a Cluster, the statement always
+// goes to the primary.
+func (r *Repository[T]) Insert(ctx context.Context, item *T) error {
+	ctx, db := r.exec(WithPrimary(ctx), "insert")
+	var columns, params []string
+	var args []interface{}
+	for _, column := range r.columns {
+		if contains(r.Generated, column) {
+			continue
+		}
+		value := r.field(item, column)
+		if column == r.Version {
+			value = 1
+		}
+		args = append(args, value)
+		columns = append(columns, column)
+		params = append(params, fmt.Sprintf("$%d", len(args)))
+	}
+	query := fmt.Sprintf(`INSERT INTO %s (%s) VALUES (%s) RETURNING %s`,
+		r.table, strings.Join(columns, ", "), strings.Join(params, ", "), r.selectList())
+	return db.QueryRowxContext(ctx, query, args...).StructScan(item)
+}
+
+// Update writes item and updates it with the stored row. With a version
+// column, the update only applies if the stored version still equals item's,
+// and ErrConflict is returned otherwise. Like Insert, it runs on the primary.
+func (r *Repository[T]) Update(ctx context.Context, item *T) error {
+	ctx, db := r.exec(WithPrimary(ctx), "update")
+	var set []string
+	var args []interface{}
+	for _, column := range r.columns {
+		if column == r.Key || column == r.Version || contains(r.Generated, column) {
+			continue
+		}
+		args = append(args, r.field(item, column))
+		set = append(set, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+	args = append(args, r.field(item, r.Key))
+	where := fmt.Sprintf("%s = $%d", r.Key, len(args))
+	if r.Version != "" {
+		set = append(set, fmt.Sprintf("%s = %s + 1", r.Version, r.Version))
+		args = append(args, r.field(item, r.Version))
+		where += fmt.Sprintf(" AND %s = $%d", r.Version, len(args))
+	}
+	query := fmt.Sprintf(`UPDATE %s SET %s WHERE %s RETURNING %s`,
+		r.table, strings.Join(set, ", "), where, r.selectList())
+
+	err := db.QueryRowxContext(ctx, query, args...).StructScan(item)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if r.Version == "" {
+		return ErrNotFound
+	}
+	var exists bool
+	query = fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE %s = $1)`, r.table, r.Key)
+	if err := db.GetContext(ctx, &exists, query, r.field(item, r.Key)); err != nil {
+		return err
+	}
+	if exists {
+		return ErrConflict
+	}
+	return ErrNotFound
+}
+
+// Delete removes the row with the given key, or returns ErrNotFound.
+func (r *Repository[T]) Delete(ctx context.Context, id interface{}) error {
+	ctx, db := r.exec(ctx, "delete")
+	res, err := db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE %s = $1`, r.table, r.Key), id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package database_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/MuxSphere/microkit/shared/database"
+	"github.com/MuxSphere/microkit/shared/database/databasetest"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type widget struct {
+	ID        int64     `db:"id"`
+	Name      string    `db:"name"`
+	Status    string    `db:"status"`
+	Version   int       `db:"version"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+var widgetColumns = []string{"id", "name", "status", "version", "created_at"}
+
+func newWidgetRepo(t *testing.T) (*database.Repository[widget], sqlmock.Sqlmock) {
+	db, mock, err := databasetest.New()
+	require.NoError(t, err)
+	repo := database.NewRepository[widget](db, "widgets")
+	repo.Generated = []string{"id", "created_at"}
+	repo.Sortable = []string{"name"}
+	repo.Filterable = []string{"status"}
+	return repo, mock
+}
+
+func TestRepositoryCRUD(t *testing.T) {
+	repo, mock := newWidgetRepo(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO widgets (name, status, version) VALUES ($1, $2, $3) RETURNING id, name, status, version, created_at`)).
+		WithArgs("gear", "active", 1).
+		WillReturnRows(sqlmock.NewRows(widgetColumns).AddRow(1, "gear", "active", 1, now))
+	w := &widget{Name: "gear", Status: "active"}
+	require.NoError(t, repo.Insert(ctx, w))
+	assert.Equal(t, int64(1), w.ID)
+	assert.Equal(t, 1, w.Version)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, status, version, created_at FROM widgets WHERE id = $1`)).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows(widgetColumns))
+	_, err := repo.Get(ctx, 2)
+	assert.ErrorIs(t, err, database.ErrNotFound)
+
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM widgets WHERE id = $1`)).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.Delete(ctx, 1))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepositoryOptimisticLocking(t *testing.T) {
+	repo, mock := newWidgetRepo(t)
+	ctx := context.Background()
+	update := regexp.QuoteMeta(`UPDATE widgets SET name = $1, status = $2, version = version + 1 WHERE id = $3 AND version = $4 RETURNING`)
+
+	mock.ExpectQuery(update).WithArgs("gear", "retired", 1, 1).
+		WillReturnRows(sqlmock.NewRows(widgetColumns).AddRow(1, "gear", "retired", 2, time.Now()))
+	w := &widget{ID: 1, Name: "gear", Status: "retired", Version: 1}
+	require.NoError(t, repo.Update(ctx, w))
+	assert.Equal(t, 2, w.Version)
+
+	stale := &widget{ID: 1, Name: "gear", Status: "active", Version: 1}
+	mock.ExpectQuery(update).WithArgs("gear", "active", 1, 1).WillReturnRows(sqlmock.NewRows(widgetColumns))
+	mock.ExpectQuery(`SELECT EXISTS`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	assert.ErrorIs(t, repo.Update(ctx, stale), database.ErrConflict)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepositoryKeysetPagination(t *testing.T) {
+	repo, mock := newWidgetRepo(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM widgets WHERE status = $1 ORDER BY name DESC, id DESC LIMIT $2`)).
+		WithArgs("active", 3).
+		WillReturnRows(sqlmock.NewRows(widgetColumns).
+			AddRow(3, "c", "active", 1, now).
+			AddRow(2, "b", "active", 1, now).
+			AddRow(1, "a", "active", 1, now))
+	opts := database.ListOptions{Limit: 2, Sort: "name", Desc: true, Filters: map[string]string{"status": "active"}}
+	page, err := repo.List(ctx, opts)
+	require.NoError(t, err)
+	require.Len(t, page.Items, 2)
+	require.NotEmpty(t, page.NextCursor)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM widgets WHERE status = $1 AND (name, id) < ($2, $3) ORDER BY name DESC, id DESC LIMIT $4`)).
+		WithArgs("active", "b", "2", 3).
+		WillReturnRows(sqlmock.NewRows(widgetColumns).AddRow(1, "a", "active", 1, now))
+	opts.Cursor = page.NextCursor
+	page, err = repo.List(ctx, opts)
+	require.NoError(t, err)
+	assert.Len(t, page.Items, 1)
+	assert.Empty(t, page.NextCursor)
+
+	// A cursor only works with the ordering it was issued for
+	_, err = repo.List(ctx, database.ListOptions{Cursor: opts.Cursor})
+	assert.ErrorIs(t, err, database.ErrInvalidCursor)
+	_, err = repo.List(ctx, database.ListOptions{Sort: "created_at"})
+	assert.ErrorIs(t, err, database.ErrInvalidSort)
+	_, err = repo.List(ctx, database.ListOptions{Filters: map[string]string{"name": "a"}})
+	assert.ErrorIs(t, err, database.ErrInvalidFilter)
+	assert.True(t, database.IsInvalidListOptions(err))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestParseListOptions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/widgets?limit=10&cursor=abc&sort=-name&filter[status]=active", nil)
+
+	opts, err := database.ParseListOptions(c)
+	require.NoError(t, err)
+	assert.Equal(t, database.ListOptions{
+		Limit:   10,
+		Cursor:  "abc",
+		Sort:    "name",
+		Desc:    true,
+		Filters: map[string]string{"status": "active"},
+	}, opts)
+
+	c, _ = gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/widgets?limit=many", nil)
+	_, err = database.ParseListOptions(c)
+	assert.ErrorIs(t, err, database.ErrInvalidLimit)
+}
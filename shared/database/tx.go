@@ -0,0 +1,155 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// TxOptions configures WithTx.
+type TxOptions struct {
+	Isolation sql.IsolationLevel
+	ReadOnly  bool
+
+	// MaxAttempts is how often the function runs when the transaction fails
+	// with a serialization failure or deadlock. Zero means 3.
+	MaxAttempts int
+	// Backoff is the wait before the first retry. It doubles with every
+	// retry. Zero means 50ms.
+	Backoff time.Duration
+}
+
+type txKey struct{}
+
+type txState struct {
+	tx    *sqlx.Tx
+	depth int
+}
+
+// WithTx runs fn in a transaction and commits it, or rolls it back if fn
+// returns an error or panics. The context passed to fn carries the
+// transaction, so ExecutorFrom and nested WithTx calls use it.
+//
+// A nested WithTx runs fn inside a savepoint that is rolled back on error
+// without aborting the outer transaction. The outermost call retries fn with
+// backoff when Postgres reports a serialization failure or deadlock, so fn
+// must be safe to run more than once.
+func WithTx(ctx context.Context, db DB, opts *TxOptions, fn func(ctx context.Context, tx *sqlx.Tx) error) error {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return withSavepoint(ctx, state, fn)
+	}
+
+	if opts == nil {
+		opts = &TxOptions{}
+	}
+	attempts, backoff := opts.MaxAttempts, opts.Backoff
+	if attempts <= 0 {
+		attempts = 3
+	}
+	if backoff <= 0 {
+		backoff = 50 * time.Millisecond
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		err = runTx(ctx, db, &sql.TxOptions{Isolation: opts.Isolation, ReadOnly: opts.ReadOnly}, fn)
+		if !IsRetryable(err) || attempt == attempts {
+			break
+		}
+		// Jitter keeps conflicting transactions from retrying in lockstep
+		wait := backoff + time.Duration(rand.Int63n(int64(backoff)))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		backoff *= 2
+	}
+	return err
+}
+
+func runTx(ctx context.Context, db DB, opts *sql.TxOptions, fn func(ctx context.Context, tx *sqlx.Tx) error) (err error) {
+	tx, err := db.BeginTxx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err = fn(context.WithValue(ctx, txKey{}, &txState{tx: tx}), tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func withSavepoint(ctx context.Context, state *txState, fn func(ctx context.Context, tx *sqlx.Tx) error) (err error) {
+	nested := &txState{tx: state.tx, depth: state.depth + 1}
+	name := fmt.Sprintf("sp_%d", nested.depth)
+	if _, err := state.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			state.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+			panic(p)
+		}
+		if err != nil {
+			state.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+		}
+	}()
+
+	if err = fn(context.WithValue(ctx, txKey{}, nested), state.tx); err != nil {
+		return err
+	}
+	_, err = state.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+	return err
+}
+
+// TxFromContext returns the transaction started by an enclosing WithTx.
+func TxFromContext(ctx context.Context) (*sqlx.Tx, bool) {
+	state, ok := ctx.Value(txKey{}).(*txState)
+	if !ok {
+		return nil, false
+	}
+	return state.tx, true
+}
+
+// ExecutorFrom returns the transaction carried by ctx, or db outside of one.
+// Repository functions use it to join a caller's transaction.
+func ExecutorFrom(ctx context.Context, db Executor) Executor {
+	tx, ok := TxFromContext(ctx)
+	if !ok {
+		return db
+	}
+	// Keep instrumenting queries that join the transaction
+	switch i := db.(type) {
+	case *instrumented:
+		return i.ins.Instrument(tx)
+	case *instrumentedDB:
+		return i.ins.Instrument(tx)
+	}
+	return tx
+}
+
+// IsRetryable reports whether err is a Postgres serialization failure
+// (40001) or deadlock (40P01), after which the transaction can be retried.
+func IsRetryable(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == "40001" || pqErr.Code == "40P01"
+}
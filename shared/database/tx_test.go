@@ -0,0 +1,105 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/MuxSphere/microkit/shared/database"
+	"github.com/MuxSphere/microkit/shared/database/databasetest"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithTxCommitsAndRollsBack(t *testing.T) {
+	db, mock, err := databasetest.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO items`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	err = database.WithTx(context.Background(), db, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+		// Repository code finds the transaction in the context
+		_, err := database.ExecutorFrom(ctx, db).ExecContext(ctx, `INSERT INTO items (name) VALUES ('a')`)
+		return err
+	})
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	boom := errors.New("boom")
+	err = database.WithTx(context.Background(), db, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+		return boom
+	})
+	assert.ErrorIs(t, err, boom)
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	assert.Panics(t, func() {
+		database.WithTx(context.Background(), db, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+			panic("boom")
+		})
+	})
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWithTxRetriesSerializationFailures(t *testing.T) {
+	db, mock, err := databasetest.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	conflict := &pq.Error{Code: "40001"}
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectCommit().WillReturnError(&pq.Error{Code: "40P01"})
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	calls := 0
+	err = database.WithTx(context.Background(), db, &database.TxOptions{Backoff: time.Millisecond}, func(ctx context.Context, tx *sqlx.Tx) error {
+		calls++
+		if calls == 1 {
+			return conflict
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	assert.True(t, database.IsRetryable(conflict))
+	assert.False(t, database.IsRetryable(&pq.Error{Code: "23505"}))
+	assert.False(t, database.IsRetryable(errors.New("40001")))
+}
+
+func TestWithTxNestedSavepoints(t *testing.T) {
+	db, mock, err := databasetest.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SAVEPOINT sp_1`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`SAVEPOINT sp_2`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`ROLLBACK TO SAVEPOINT sp_2`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`RELEASE SAVEPOINT sp_1`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err = database.WithTx(context.Background(), db, nil, func(ctx context.Context, outer *sqlx.Tx) error {
+		return database.WithTx(ctx, db, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+			assert.Same(t, outer, tx)
+			inner := database.WithTx(ctx, db, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+				return errors.New("optional step failed")
+			})
+			assert.Error(t, inner)
+			return nil
+		})
+	})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -1,13 +1,25 @@
 package discovery
 
 import (
+	"context"
 	"fmt"
+	"math/rand"
+	"sync"
+	"time"
 
 	"github.com/hashicorp/consul/api"
 )
 
+var (
+	_ Registry = (*ServiceDiscovery)(nil)
+	_ Resolver = (*ServiceDiscovery)(nil)
+)
+
 type ServiceDiscovery struct {
 	client *api.Client
+
+	mu         sync.Mutex
+	heartbeats map[string]context.CancelFunc
 }
 
 func NewServiceDiscovery(address string) (*ServiceDiscovery, error) {
@@ -17,37 +29,136 @@ func NewServiceDiscovery(address string) (*ServiceDiscovery, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &ServiceDiscovery{client: client}, nil
+	return &ServiceDiscovery{client: client, heartbeats: make(map[string]context.CancelFunc)}, nil
+}
+
+// Client returns the underlying Consul client, e.g. for locks or KV access.
+func (sd *ServiceDiscovery) Client() *api.Client {
+	return sd.client
 }
 
-func (sd *ServiceDiscovery) RegisterService(name, host string, port int) error {
-	reg := &api.AgentServiceRegistration{
-		ID:      fmt.Sprintf("%s-%s-%d", name, host, port),
+// RegisterService registers the instance with the local agent. With WithTTL
+// it also starts a heartbeat that keeps the TTL check passing until
+// DeregisterService is called.
+func (sd *ServiceDiscovery) RegisterService(name, host string, port int, opts ...RegisterOption) error {
+	reg := newRegistration(name, host, port, opts)
+
+	check := &api.AgentServiceCheck{
+		CheckID:  checkID(reg.ID),
+		HTTP:     fmt.Sprintf("http://%s:%d/health", host, port),
+		Interval: "10s",
+		Timeout:  "5s",
+	}
+	if reg.TTL > 0 {
+		check = &api.AgentServiceCheck{
+			CheckID: checkID(reg.ID),
+			TTL:     reg.TTL.String(),
+		}
+	}
+	if reg.DeregisterCriticalAfter > 0 {
+		check.DeregisterCriticalServiceAfter = reg.DeregisterCriticalAfter.String()
+	}
+
+	service := &api.AgentServiceRegistration{
+		ID:      reg.ID,
 		Name:    name,
 		Address: host,
 		Port:    port,
-		Check: &api.AgentServiceCheck{
-			HTTP:     fmt.Sprintf("http://%s:%d/health", host, port),
-			Interval: "10s",
-			Timeout:  "5s",
-		},
+		Tags:    reg.Tags,
+		Meta:    reg.Meta,
+		Check:   check,
+	}
+	if reg.GRPCPort > 0 {
+		service.TaggedAddresses = map[string]api.ServiceAddress{
+			"grpc": {Address: host, Port: reg.GRPCPort},
+		}
+	}
+	if err := sd.client.Agent().ServiceRegister(service); err != nil {
+		return err
+	}
+
+	if reg.TTL > 0 {
+		if err := sd.client.Agent().PassTTL(checkID(reg.ID), ""); err != nil {
+			return err
+		}
+		sd.startHeartbeat(reg.ID, reg.TTL)
 	}
-	return sd.client.Agent().ServiceRegister(reg)
+	return nil
 }
 
-func (sd *ServiceDiscovery) DeregisterService(name, host string, port int) error {
-	return sd.client.Agent().ServiceDeregister(fmt.Sprintf("%s-%s-%d", name, host, port))
+func (sd *ServiceDiscovery) DeregisterService(name, host string, port int, opts ...RegisterOption) error {
+	id := newRegistration(name, host, port, opts).ID
+	sd.stopHeartbeat(id)
+	return sd.client.Agent().ServiceDeregister(id)
 }
 
-func (sd *ServiceDiscovery) DiscoverService(name string) (*api.AgentService, error) {
-	services, err := sd.client.Agent().Services()
+// DiscoverService returns a random instance whose health checks pass.
+func (sd *ServiceDiscovery) DiscoverService(name string, tags ...string) (*Instance, error) {
+	entries, _, err := sd.client.Health().ServiceMultipleTags(name, tags, true, nil)
 	if err != nil {
 		return nil, err
 	}
-	for _, service := range services {
-		if service.Service == name {
-			return service, nil
+	if len(entries) == 0 {
+		return nil, notFound(name, tags)
+	}
+
+	entry := entries[rand.Intn(len(entries))]
+	service := entry.Service
+	inst := &Instance{
+		ID:      service.ID,
+		Name:    service.Service,
+		Address: service.Address,
+		Port:    service.Port,
+		Tags:    service.Tags,
+		Meta:    service.Meta,
+	}
+	if inst.Address == "" {
+		inst.Address = entry.Node.Address
+	}
+	if grpc, ok := service.TaggedAddresses["grpc"]; ok {
+		inst.GRPCPort = grpc.Port
+	}
+	return inst, nil
+}
+
+// startHeartbeat passes the TTL check of id at a third of the TTL, so a single
+// failed update does not make the instance critical.
+func (sd *ServiceDiscovery) startHeartbeat(id string, ttl time.Duration) {
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+
+	if cancel, ok := sd.heartbeats[id]; ok {
+		cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	sd.heartbeats[id] = cancel
+
+	go func() {
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				// A failed update is retried on the next tick; if updates keep
+				// failing Consul marks the instance critical, as intended.
+				sd.client.Agent().PassTTL(checkID(id), "")
+			}
 		}
+	}()
+}
+
+func (sd *ServiceDiscovery) stopHeartbeat(id string) {
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+
+	if cancel, ok := sd.heartbeats[id]; ok {
+		cancel()
+		delete(sd.heartbeats, id)
 	}
-	return nil, fmt.Errorf("service %s not found", name)
+}
+
+func checkID(serviceID string) string {
+	return "service:" + serviceID
 }
@@ -0,0 +1,123 @@
+package discovery
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Instance is one running instance of a service.
+type Instance struct {
+	ID       string
+	Name     string
+	Scheme   string
+	Address  string
+	Port     int
+	GRPCPort int
+	Tags     []string
+	Meta     map[string]string
+}
+
+// URL returns the base URL of the instance. The scheme defaults to http.
+func (i *Instance) URL() string {
+	scheme := i.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s:%d", scheme, i.Address, i.Port)
+}
+
+// Registry announces service instances.
+type Registry interface {
+	RegisterService(name, host string, port int, opts ...RegisterOption) error
+	DeregisterService(name, host string, port int, opts ...RegisterOption) error
+}
+
+// Resolver finds a healthy instance of a service carrying all of the given
+// tags.
+type Resolver interface {
+	DiscoverService(name string, tags ...string) (*Instance, error)
+}
+
+// Discovery is a registry backend that both announces and finds instances.
+type Discovery interface {
+	Registry
+	Resolver
+}
+
+// Open returns the backend selected by the scheme of rawURL:
+//
+//	consul:8500, consul://consul:8500, http(s)://consul:8500  Consul agent
+//	static://?service-a=http://service-a:8080                 fixed instance list
+//	dns://[server]/default.svc.cluster.local?service=http     DNS SRV lookups
+//	mem://                                                    in-process registry
+//
+// static is merged into the instance list of the static backend and ignored by
+// the others.
+func Open(rawURL string, static map[string][]string) (Discovery, error) {
+	scheme, _, found := strings.Cut(rawURL, "://")
+	if !found {
+		return NewServiceDiscovery(rawURL)
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	switch scheme {
+	case "consul":
+		return NewServiceDiscovery(u.Host)
+	case "http", "https":
+		return NewServiceDiscovery(rawURL)
+	case "static":
+		services := make(map[string][]string)
+		for name, urls := range static {
+			services[name] = append(services[name], urls...)
+		}
+		for name, urls := range u.Query() {
+			services[name] = append(services[name], urls...)
+		}
+		return NewStaticRegistry(services)
+	case "dns":
+		q := u.Query()
+		service, proto := q.Get("service"), q.Get("proto")
+		if service == "" {
+			service = "http"
+		}
+		if proto == "" {
+			proto = "tcp"
+		}
+		return NewDNSRegistry(u.Host, service, proto, strings.Trim(u.Path, "/")), nil
+	case "mem", "memory":
+		return NewMemoryRegistry(), nil
+	default:
+		return nil, fmt.Errorf("unsupported discovery scheme %q", scheme)
+	}
+}
+
+func instanceID(name, host string, port int) string {
+	return fmt.Sprintf("%s-%s-%d", name, host, port)
+}
+
+// pick returns the instances carrying tags in turn, advancing next[name].
+func pick(instances []*Instance, next map[string]int, name string, tags []string) (*Instance, error) {
+	var matching []*Instance
+	for _, inst := range instances {
+		if inst.HasTags(tags...) {
+			matching = append(matching, inst)
+		}
+	}
+	if len(matching) == 0 {
+		return nil, notFound(name, tags)
+	}
+	inst := *matching[next[name]%len(matching)]
+	next[name]++
+	return &inst, nil
+}
+
+func notFound(name string, tags []string) error {
+	if len(tags) > 0 {
+		return fmt.Errorf("service %s with tags %v not found", name, tags)
+	}
+	return fmt.Errorf("service %s not found", name)
+}
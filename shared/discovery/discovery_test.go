@@ -0,0 +1,94 @@
+package discovery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenSelectsBackend(t *testing.T) {
+	tests := []struct {
+		url  string
+		want interface{}
+	}{
+		{"consul:8500", &ServiceDiscovery{}},
+		{"consul://consul:8500", &ServiceDiscovery{}},
+		{"http://consul:8500", &ServiceDiscovery{}},
+		{"static://", &StaticRegistry{}},
+		{"dns:///default.svc.cluster.local", &DNSRegistry{}},
+		{"mem://", &MemoryRegistry{}},
+	}
+	for _, tt := range tests {
+		d, err := Open(tt.url, nil)
+		require.NoError(t, err, tt.url)
+		assert.IsType(t, tt.want, d, tt.url)
+	}
+
+	_, err := Open("etcd://localhost:2379", nil)
+	assert.Error(t, err)
+}
+
+func TestStaticRegistry(t *testing.T) {
+	d, err := Open("static://?service-b=http://b1:8080&service-b=http://b2:8080", map[string][]string{
+		"service-a": {"https://service-a"},
+	})
+	require.NoError(t, err)
+
+	a, err := d.DiscoverService("service-a")
+	require.NoError(t, err)
+	assert.Equal(t, "https://service-a:443", a.URL())
+
+	b1, err := d.DiscoverService("service-b")
+	require.NoError(t, err)
+	b2, err := d.DiscoverService("service-b")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"http://b1:8080", "http://b2:8080"}, []string{b1.URL(), b2.URL()})
+
+	_, err = d.DiscoverService("service-c")
+	assert.Error(t, err)
+
+	_, err = NewStaticRegistry(map[string][]string{"service-a": {"service-a:8080"}})
+	assert.Error(t, err)
+}
+
+func TestMemoryRegistry(t *testing.T) {
+	m := NewMemoryRegistry()
+	require.NoError(t, m.RegisterService("service-a", "10.0.0.1", 8080))
+	require.NoError(t, m.RegisterService("service-a", "10.0.0.2", 8080))
+
+	first, err := m.DiscoverService("service-a")
+	require.NoError(t, err)
+	second, err := m.DiscoverService("service-a")
+	require.NoError(t, err)
+	assert.NotEqual(t, first.ID, second.ID)
+
+	require.NoError(t, m.DeregisterService("service-a", "10.0.0.1", 8080))
+	require.NoError(t, m.DeregisterService("service-a", "10.0.0.2", 8080))
+	_, err = m.DiscoverService("service-a")
+	assert.Error(t, err)
+}
+
+func TestMemoryRegistryTags(t *testing.T) {
+	m := NewMemoryRegistry()
+	require.NoError(t, m.RegisterService("service-a", "10.0.0.1", 8080, WithVersion("1"), WithZone("eu")))
+	require.NoError(t, m.RegisterService("service-a", "10.0.0.2", 8080, WithVersion("2"), WithZone("eu"), WithGRPCPort(50051)))
+
+	inst, err := m.DiscoverService("service-a", VersionTag("2"), ZoneTag("eu"))
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.2", inst.Address)
+	assert.Equal(t, 50051, inst.GRPCPort)
+
+	_, err = m.DiscoverService("service-a", VersionTag("2"), ZoneTag("us"))
+	assert.Error(t, err)
+
+	// Re-registering with the same ID replaces the instance.
+	require.NoError(t, m.RegisterService("service-a", "10.0.0.9", 8080, WithID("a-1")))
+	require.NoError(t, m.RegisterService("service-a", "10.0.0.10", 8080, WithID("a-1"), WithVersion("3")))
+	inst, err = m.DiscoverService("service-a", VersionTag("3"))
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.10", inst.Address)
+	require.NoError(t, m.DeregisterService("service-a", "10.0.0.10", 8080, WithID("a-1")))
+	_, err = m.DiscoverService("service-a", VersionTag("3"))
+	assert.Error(t, err)
+}
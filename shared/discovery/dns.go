@@ -0,0 +1,90 @@
+package discovery
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+)
+
+var (
+	_ Registry = (*DNSRegistry)(nil)
+	_ Resolver = (*DNSRegistry)(nil)
+)
+
+// DNSRegistry resolves services with DNS SRV lookups. With Kubernetes headless
+// services, a service named service-a in the default namespace is found at
+// _http._tcp.service-a.default.svc.cluster.local. Registration is left to the
+// platform and is a no-op.
+type DNSRegistry struct {
+	resolver *net.Resolver
+	service  string
+	proto    string
+	domain   string
+
+	// Timeout bounds each lookup.
+	Timeout time.Duration
+}
+
+// NewDNSRegistry looks up _service._proto.<name>.<domain>. If server is empty
+// the system resolver is used, otherwise queries go to server (host:port).
+func NewDNSRegistry(server, service, proto, domain string) *DNSRegistry {
+	resolver := net.DefaultResolver
+	if server != "" {
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, server)
+			},
+		}
+	}
+	return &DNSRegistry{
+		resolver: resolver,
+		service:  service,
+		proto:    proto,
+		domain:   domain,
+		Timeout:  5 * time.Second,
+	}
+}
+
+func (d *DNSRegistry) RegisterService(name, host string, port int, opts ...RegisterOption) error {
+	return nil
+}
+
+func (d *DNSRegistry) DeregisterService(name, host string, port int, opts ...RegisterOption) error {
+	return nil
+}
+
+// DiscoverService returns the first SRV record, which the resolver orders by
+// priority and randomizes by weight. SRV records carry no tags, so a lookup
+// with tags fails.
+func (d *DNSRegistry) DiscoverService(name string, tags ...string) (*Instance, error) {
+	if len(tags) > 0 {
+		return nil, notFound(name, tags)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout)
+	defer cancel()
+
+	fqdn := name
+	if d.domain != "" {
+		fqdn = name + "." + d.domain
+	}
+	_, records, err := d.resolver.LookupSRV(ctx, d.service, d.proto, fqdn)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, notFound(name, nil)
+	}
+
+	target := strings.TrimSuffix(records[0].Target, ".")
+	port := int(records[0].Port)
+	return &Instance{
+		ID:      instanceID(name, target, port),
+		Name:    name,
+		Address: target,
+		Port:    port,
+	}, nil
+}
@@ -0,0 +1,63 @@
+package discovery
+
+import "sync"
+
+var (
+	_ Registry = (*MemoryRegistry)(nil)
+	_ Resolver = (*MemoryRegistry)(nil)
+)
+
+// MemoryRegistry keeps registrations in process. It is meant for tests and
+// local runs without Consul.
+type MemoryRegistry struct {
+	mu        sync.Mutex
+	instances map[string][]*Instance
+	next      map[string]int
+}
+
+func NewMemoryRegistry() *MemoryRegistry {
+	return &MemoryRegistry{
+		instances: make(map[string][]*Instance),
+		next:      make(map[string]int),
+	}
+}
+
+// RegisterService adds the instance, replacing an earlier registration with
+// the same ID.
+func (m *MemoryRegistry) RegisterService(name, host string, port int, opts ...RegisterOption) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	inst := newRegistration(name, host, port, opts).instance(name, host, port)
+	for i, existing := range m.instances[name] {
+		if existing.ID == inst.ID {
+			m.instances[name][i] = inst
+			return nil
+		}
+	}
+	m.instances[name] = append(m.instances[name], inst)
+	return nil
+}
+
+func (m *MemoryRegistry) DeregisterService(name, host string, port int, opts ...RegisterOption) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := newRegistration(name, host, port, opts).ID
+	kept := m.instances[name][:0]
+	for _, inst := range m.instances[name] {
+		if inst.ID != id {
+			kept = append(kept, inst)
+		}
+	}
+	m.instances[name] = kept
+	return nil
+}
+
+// DiscoverService returns the matching instances of name in turn.
+func (m *MemoryRegistry) DiscoverService(name string, tags ...string) (*Instance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return pick(m.instances[name], m.next, name, tags)
+}
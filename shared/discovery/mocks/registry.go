@@ -0,0 +1,161 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	discovery "github.com/MuxSphere/microkit/shared/discovery"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Registry is an autogenerated mock type for the Registry type
+type Registry struct {
+	mock.Mock
+}
+
+type Registry_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *Registry) EXPECT() *Registry_Expecter {
+	return &Registry_Expecter{mock: &_m.Mock}
+}
+
+// DeregisterService provides a mock function with given fields: name, host, port, opts
+func (_m *Registry) DeregisterService(name string, host string, port int, opts ...discovery.RegisterOption) error {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, name, host, port)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeregisterService")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, int, ...discovery.RegisterOption) error); ok {
+		r0 = rf(name, host, port, opts...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Registry_DeregisterService_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeregisterService'
+type Registry_DeregisterService_Call struct {
+	*mock.Call
+}
+
+// DeregisterService is a helper method to define mock.On call
+//   - name string
+//   - host string
+//   - port int
+//   - opts ...discovery.RegisterOption
+func (_e *Registry_Expecter) DeregisterService(name interface{}, host interface{}, port interface{}, opts ...interface{}) *Registry_DeregisterService_Call {
+	return &Registry_DeregisterService_Call{Call: _e.mock.On("DeregisterService",
+		append([]interface{}{name, host, port}, opts...)...)}
+}
+
+func (_c *Registry_DeregisterService_Call) Run(run func(name string, host string, port int, opts ...discovery.RegisterOption)) *Registry_DeregisterService_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]discovery.RegisterOption, len(args)-3)
+		for i, a := range args[3:] {
+			if a != nil {
+				variadicArgs[i] = a.(discovery.RegisterOption)
+			}
+		}
+		run(args[0].(string), args[1].(string), args[2].(int), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *Registry_DeregisterService_Call) Return(_a0 error) *Registry_DeregisterService_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Registry_DeregisterService_Call) RunAndReturn(run func(string, string, int, ...discovery.RegisterOption) error) *Registry_DeregisterService_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RegisterService provides a mock function with given fields: name, host, port, opts
+func (_m *Registry) RegisterService(name string, host string, port int, opts ...discovery.RegisterOption) error {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, name, host, port)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RegisterService")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, int, ...discovery.RegisterOption) error); ok {
+		r0 = rf(name, host, port, opts...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Registry_RegisterService_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RegisterService'
+type Registry_RegisterService_Call struct {
+	*mock.Call
+}
+
+// RegisterService is a helper method to define mock.On call
+//   - name string
+//   - host string
+//   - port int
+//   - opts ...discovery.RegisterOption
+func (_e *Registry_Expecter) RegisterService(name interface{}, host interface{}, port interface{}, opts ...interface{}) *Registry_RegisterService_Call {
+	return &Registry_RegisterService_Call{Call: _e.mock.On("RegisterService",
+		append([]interface{}{name, host, port}, opts...)...)}
+}
+
+func (_c *Registry_RegisterService_Call) Run(run func(name string, host string, port int, opts ...discovery.RegisterOption)) *Registry_RegisterService_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]discovery.RegisterOption, len(args)-3)
+		for i, a := range args[3:] {
+			if a != nil {
+				variadicArgs[i] = a.(discovery.RegisterOption)
+			}
+		}
+		run(args[0].(string), args[1].(string), args[2].(int), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *Registry_RegisterService_Call) Return(_a0 error) *Registry_RegisterService_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Registry_RegisterService_Call) RunAndReturn(run func(string, string, int, ...discovery.RegisterOption) error) *Registry_RegisterService_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewRegistry creates a new instance of Registry. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewRegistry(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Registry {
+	mock := &Registry{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,108 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	discovery "github.com/MuxSphere/microkit/shared/discovery"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Resolver is an autogenerated mock type for the Resolver type
+type Resolver struct {
+	mock.Mock
+}
+
+type Resolver_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *Resolver) EXPECT() *Resolver_Expecter {
+	return &Resolver_Expecter{mock: &_m.Mock}
+}
+
+// DiscoverService provides a mock function with given fields: name, tags
+func (_m *Resolver) DiscoverService(name string, tags ...string) (*discovery.Instance, error) {
+	_va := make([]interface{}, len(tags))
+	for _i := range tags {
+		_va[_i] = tags[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, name)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DiscoverService")
+	}
+
+	var r0 *discovery.Instance
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, ...string) (*discovery.Instance, error)); ok {
+		return rf(name, tags...)
+	}
+	if rf, ok := ret.Get(0).(func(string, ...string) *discovery.Instance); ok {
+		r0 = rf(name, tags...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*discovery.Instance)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, ...string) error); ok {
+		r1 = rf(name, tags...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Resolver_DiscoverService_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DiscoverService'
+type Resolver_DiscoverService_Call struct {
+	*mock.Call
+}
+
+// DiscoverService is a helper method to define mock.On call
+//   - name string
+//   - tags ...string
+func (_e *Resolver_Expecter) DiscoverService(name interface{}, tags ...interface{}) *Resolver_DiscoverService_Call {
+	return &Resolver_DiscoverService_Call{Call: _e.mock.On("DiscoverService",
+		append([]interface{}{name}, tags...)...)}
+}
+
+func (_c *Resolver_DiscoverService_Call) Run(run func(name string, tags ...string)) *Resolver_DiscoverService_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]string, len(args)-1)
+		for i, a := range args[1:] {
+			if a != nil {
+				variadicArgs[i] = a.(string)
+			}
+		}
+		run(args[0].(string), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *Resolver_DiscoverService_Call) Return(_a0 *discovery.Instance, _a1 error) *Resolver_DiscoverService_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Resolver_DiscoverService_Call) RunAndReturn(run func(string, ...string) (*discovery.Instance, error)) *Resolver_DiscoverService_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewResolver creates a new instance of Resolver. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewResolver(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Resolver {
+	mock := &Resolver{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
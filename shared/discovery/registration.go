@@ -0,0 +1,119 @@
+package discovery
+
+import (
+	"strings"
+	"time"
+)
+
+// Registration describes how an instance is announced. Backends without
+// health checks or metadata ignore the fields they do not support.
+type Registration struct {
+	// ID identifies the instance. It defaults to name-host-port, so a restarted
+	// instance replaces its previous registration instead of adding one.
+	ID       string
+	Tags     []string
+	Meta     map[string]string
+	GRPCPort int
+	// TTL switches the HTTP /health check to a TTL check kept alive by a
+	// heartbeat from the registering process.
+	TTL time.Duration
+	// DeregisterCriticalAfter removes the instance once its check has been
+	// critical for this long.
+	DeregisterCriticalAfter time.Duration
+}
+
+// RegisterOption configures a Registration.
+type RegisterOption func(*Registration)
+
+func WithID(id string) RegisterOption {
+	return func(r *Registration) { r.ID = id }
+}
+
+func WithTags(tags ...string) RegisterOption {
+	return func(r *Registration) { r.Tags = append(r.Tags, tags...) }
+}
+
+func WithMeta(key, value string) RegisterOption {
+	return func(r *Registration) {
+		if r.Meta == nil {
+			r.Meta = make(map[string]string)
+		}
+		r.Meta[key] = value
+	}
+}
+
+// WithVersion tags the instance with VersionTag(version) and records the
+// version in its metadata.
+func WithVersion(version string) RegisterOption {
+	return func(r *Registration) {
+		WithTags(VersionTag(version))(r)
+		WithMeta("version", version)(r)
+	}
+}
+
+// WithZone tags the instance with ZoneTag(zone) and records the zone in its
+// metadata.
+func WithZone(zone string) RegisterOption {
+	return func(r *Registration) {
+		WithTags(ZoneTag(zone))(r)
+		WithMeta("zone", zone)(r)
+	}
+}
+
+// WithGRPCPort announces a gRPC port as the "grpc" tagged address.
+func WithGRPCPort(port int) RegisterOption {
+	return func(r *Registration) { r.GRPCPort = port }
+}
+
+func WithTTL(ttl time.Duration) RegisterOption {
+	return func(r *Registration) { r.TTL = ttl }
+}
+
+func WithDeregisterCriticalAfter(d time.Duration) RegisterOption {
+	return func(r *Registration) { r.DeregisterCriticalAfter = d }
+}
+
+func VersionTag(version string) string {
+	return "version=" + version
+}
+
+func ZoneTag(zone string) string {
+	return "zone=" + zone
+}
+
+func newRegistration(name, host string, port int, opts []RegisterOption) Registration {
+	reg := Registration{ID: instanceID(name, host, port)}
+	for _, opt := range opts {
+		opt(&reg)
+	}
+	return reg
+}
+
+func (r Registration) instance(name, host string, port int) *Instance {
+	return &Instance{
+		ID:       r.ID,
+		Name:     name,
+		Address:  host,
+		Port:     port,
+		GRPCPort: r.GRPCPort,
+		Tags:     r.Tags,
+		Meta:     r.Meta,
+	}
+}
+
+// HasTags reports whether the instance carries every one of tags.
+func (i *Instance) HasTags(tags ...string) bool {
+	for _, tag := range tags {
+		found := false
+		for _, t := range i.Tags {
+			if strings.EqualFold(t, tag) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
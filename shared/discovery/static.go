@@ -0,0 +1,84 @@
+package discovery
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+)
+
+var (
+	_ Registry = (*StaticRegistry)(nil)
+	_ Resolver = (*StaticRegistry)(nil)
+)
+
+// StaticRegistry resolves services from a fixed list of URLs, such as the
+// SERVICE_A_URL and SERVICE_B_URL settings. Registration is a no-op.
+type StaticRegistry struct {
+	mu        sync.Mutex
+	instances map[string][]*Instance
+	next      map[string]int
+}
+
+// NewStaticRegistry returns a registry serving the given base URLs per service
+// name. Services with several URLs are resolved in turn.
+func NewStaticRegistry(services map[string][]string) (*StaticRegistry, error) {
+	s := &StaticRegistry{
+		instances: make(map[string][]*Instance),
+		next:      make(map[string]int),
+	}
+	for name, urls := range services {
+		for _, raw := range urls {
+			inst, err := parseInstance(name, raw)
+			if err != nil {
+				return nil, err
+			}
+			s.instances[name] = append(s.instances[name], inst)
+		}
+	}
+	return s, nil
+}
+
+func (s *StaticRegistry) RegisterService(name, host string, port int, opts ...RegisterOption) error {
+	return nil
+}
+
+func (s *StaticRegistry) DeregisterService(name, host string, port int, opts ...RegisterOption) error {
+	return nil
+}
+
+// DiscoverService returns the URLs of name in turn. Static instances carry no
+// tags, so a lookup with tags finds nothing.
+func (s *StaticRegistry) DiscoverService(name string, tags ...string) (*Instance, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return pick(s.instances[name], s.next, name, tags)
+}
+
+func parseInstance(name, raw string) (*Instance, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid URL %q for service %s", raw, name)
+	}
+
+	port := 80
+	if u.Scheme == "https" {
+		port = 443
+	}
+	if p := u.Port(); p != "" {
+		if port, err = strconv.Atoi(p); err != nil {
+			return nil, err
+		}
+	}
+	return &Instance{
+		ID:      instanceID(name, u.Hostname(), port),
+		Name:    name,
+		Scheme:  u.Scheme,
+		Address: u.Hostname(),
+		Port:    port,
+	}, nil
+}
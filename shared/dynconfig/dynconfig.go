@@ -0,0 +1,222 @@
+// Package dynconfig layers Consul KV on top of the environment and defaults
+// read through viper, and notifies subscribers when a value changes.
+package dynconfig
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// KV lists keys under a prefix with Consul's blocking query semantics. It is
+// implemented by *api.KV.
+type KV interface {
+	List(prefix string, q *api.QueryOptions) (api.KVPairs, *api.QueryMeta, error)
+}
+
+// Value is a type a setting can be converted to.
+type Value interface {
+	string | int | int64 | float64 | bool | time.Duration
+}
+
+// Source serves settings from Consul KV keys under a prefix, falling back to
+// viper. A key config/service-a/rate_limit overrides RATE_LIMIT.
+type Source struct {
+	kv     KV
+	prefix string
+	logger *zap.Logger
+
+	mu          sync.Mutex
+	values      map[string]string
+	index       uint64
+	subscribers map[string][]func(string)
+
+	// Viper holds the environment and defaults the KV values are layered
+	// over. Defaults to the global instance.
+	Viper *viper.Viper
+	// WaitTime bounds each blocking query.
+	WaitTime time.Duration
+	// RetryInterval is the wait after a failed query.
+	RetryInterval time.Duration
+}
+
+// New returns a Source reading keys under prefix from kv. With a nil kv only
+// viper is used and Watch returns immediately.
+func New(kv KV, prefix string, logger *zap.Logger) *Source {
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return &Source{
+		kv:            kv,
+		prefix:        prefix,
+		logger:        logger,
+		values:        make(map[string]string),
+		subscribers:   make(map[string][]func(string)),
+		Viper:         viper.GetViper(),
+		WaitTime:      5 * time.Minute,
+		RetryInterval: 5 * time.Second,
+	}
+}
+
+// Load fetches the current KV values and applies them to viper, so that a
+// config.Load afterwards sees them.
+func (s *Source) Load(ctx context.Context) error {
+	if s.kv == nil {
+		return nil
+	}
+	return s.poll(ctx, 0)
+}
+
+// Get returns the effective value of key.
+func (s *Source) Get(key string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if v, ok := s.values[normalize(key)]; ok {
+		return v
+	}
+	return s.Viper.GetString(key)
+}
+
+// Watch follows KV changes with blocking queries until ctx is cancelled.
+func (s *Source) Watch(ctx context.Context) {
+	if s.kv == nil {
+		return
+	}
+	for ctx.Err() == nil {
+		s.mu.Lock()
+		index := s.index
+		s.mu.Unlock()
+
+		if err := s.poll(ctx, index); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			s.logger.Warn("Failed to watch configuration", zap.String("prefix", s.prefix), zap.Error(err))
+			select {
+			case <-ctx.Done():
+			case <-time.After(s.RetryInterval):
+			}
+		}
+	}
+}
+
+// Subscribe calls fn with the new value of key each time it changes. Values
+// that cannot be converted to T are logged and skipped.
+func Subscribe[T Value](s *Source, key string, fn func(T)) {
+	key = normalize(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.subscribers[key] = append(s.subscribers[key], func(raw string) {
+		v, err := convert[T](raw)
+		if err != nil {
+			s.logger.Warn("Ignoring invalid configuration value", zap.String("key", key), zap.String("value", raw), zap.Error(err))
+			return
+		}
+		fn(v)
+	})
+}
+
+func (s *Source) poll(ctx context.Context, index uint64) error {
+	q := (&api.QueryOptions{WaitIndex: index, WaitTime: s.WaitTime}).WithContext(ctx)
+	pairs, meta, err := s.kv.List(s.prefix, q)
+	if err != nil {
+		return err
+	}
+
+	values := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key := normalize(strings.TrimPrefix(pair.Key, s.prefix))
+		if key == "" || strings.HasSuffix(pair.Key, "/") {
+			continue
+		}
+		values[key] = string(pair.Value)
+	}
+
+	s.mu.Lock()
+	// The index can go backwards, e.g. after a Consul restore; start over.
+	if meta.LastIndex < index {
+		s.index = 0
+	} else {
+		s.index = meta.LastIndex
+	}
+	changed := s.apply(values)
+	s.mu.Unlock()
+
+	for _, c := range changed {
+		s.logger.Info("Configuration changed", zap.String("key", c.key))
+		for _, fn := range c.subscribers {
+			fn(c.value)
+		}
+	}
+	return nil
+}
+
+type change struct {
+	key         string
+	value       string
+	subscribers []func(string)
+}
+
+// apply swaps in values, updates the viper overrides and returns the keys whose
+// effective value changed. It must be called with s.mu held.
+func (s *Source) apply(values map[string]string) []change {
+	var changed []change
+	for key, v := range values {
+		if old, ok := s.values[key]; !ok || old != v {
+			s.Viper.Set(key, v)
+			changed = append(changed, change{key, v, s.subscribers[key]})
+		}
+	}
+	for key := range s.values {
+		if _, ok := values[key]; !ok {
+			// Dropping the override falls back to env and defaults
+			s.Viper.Set(key, nil)
+			changed = append(changed, change{key, s.Viper.GetString(key), s.subscribers[key]})
+		}
+	}
+	s.values = values
+	return changed
+}
+
+func normalize(key string) string {
+	return strings.ToUpper(strings.ReplaceAll(key, "/", "_"))
+}
+
+func convert[T Value](raw string) (T, error) {
+	var v T
+	var err error
+	switch p := interface{}(&v).(type) {
+	case *string:
+		*p = raw
+	case *int:
+		*p, err = cast.ToIntE(raw)
+	case *int64:
+		*p, err = cast.ToInt64E(raw)
+	case *float64:
+		*p, err = cast.ToFloat64E(raw)
+	case *bool:
+		*p, err = cast.ToBoolE(raw)
+	case *time.Duration:
+		*p, err = cast.ToDurationE(raw)
+	}
+	return v, err
+}
+
+// BindLevel sets level from LOG_LEVEL and keeps it in sync with changes.
+func BindLevel(s *Source, level zap.AtomicLevel) {
+	apply := func(v string) {
+		if err := level.UnmarshalText([]byte(v)); err != nil {
+			s.logger.Warn("Ignoring invalid log level", zap.String("level", v))
+		}
+	}
+	apply(s.Get("LOG_LEVEL"))
+	Subscribe(s, "LOG_LEVEL", apply)
+}
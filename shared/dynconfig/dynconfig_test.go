@@ -0,0 +1,104 @@
+package dynconfig
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeKV answers blocking queries from an in-memory snapshot.
+type fakeKV struct {
+	mu      sync.Mutex
+	index   uint64
+	pairs   api.KVPairs
+	changed chan struct{}
+}
+
+func newFakeKV() *fakeKV {
+	return &fakeKV{index: 1, changed: make(chan struct{})}
+}
+
+func (f *fakeKV) set(pairs ...*api.KVPair) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pairs = pairs
+	f.index++
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+func (f *fakeKV) List(prefix string, q *api.QueryOptions) (api.KVPairs, *api.QueryMeta, error) {
+	f.mu.Lock()
+	if q.WaitIndex >= f.index {
+		changed := f.changed
+		f.mu.Unlock()
+		select {
+		case <-changed:
+		case <-q.Context().Done():
+			return nil, nil, q.Context().Err()
+		}
+		f.mu.Lock()
+	}
+	defer f.mu.Unlock()
+	return f.pairs, &api.QueryMeta{LastIndex: f.index}, nil
+}
+
+func TestSourceLayersAndWatches(t *testing.T) {
+	t.Setenv("RATE_LIMIT", "100")
+	viper.AutomaticEnv()
+
+	kv := newFakeKV()
+	kv.set(&api.KVPair{Key: "config/api-gateway/rate_limit", Value: []byte("50")})
+
+	s := New(kv, "config/api-gateway", zap.NewNop())
+	require.NoError(t, s.Load(context.Background()))
+	assert.Equal(t, "50", s.Get("RATE_LIMIT"))
+	assert.Equal(t, 50, viper.GetInt("RATE_LIMIT"))
+
+	limits := make(chan int, 4)
+	Subscribe(s, "RATE_LIMIT", func(v int) { limits <- v })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Watch(ctx)
+
+	kv.set(&api.KVPair{Key: "config/api-gateway/rate_limit", Value: []byte("not a number")})
+	kv.set(&api.KVPair{Key: "config/api-gateway/rate_limit", Value: []byte("20")})
+	assert.Equal(t, 20, receive(t, limits))
+
+	// Deleting the key falls back to the environment.
+	kv.set()
+	assert.Equal(t, 100, receive(t, limits))
+	assert.Equal(t, "100", s.Get("RATE_LIMIT"))
+}
+
+func TestConvert(t *testing.T) {
+	d, err := convert[time.Duration]("1m30s")
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Second, d)
+
+	b, err := convert[bool]("true")
+	require.NoError(t, err)
+	assert.True(t, b)
+
+	_, err = convert[int]("ten")
+	assert.Error(t, err)
+}
+
+func receive(t *testing.T, ch <-chan int) int {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(time.Second):
+		t.Fatal("no change received")
+		return 0
+	}
+}
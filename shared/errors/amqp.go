@@ -0,0 +1,54 @@
+package errors
+
+import "github.com/streadway/amqp"
+
+// Decision is how a consumer settles a delivery once its handler returned.
+type Decision int
+
+const (
+	Ack Decision = iota
+	// Requeue puts the message back on its queue for another attempt.
+	Requeue
+	// DeadLetter rejects the message without requeueing, so it moves to the
+	// queue's dead letter exchange if one is configured.
+	DeadLetter
+)
+
+func (d Decision) String() string {
+	switch d {
+	case Ack:
+		return "ack"
+	case Requeue:
+		return "requeue"
+	default:
+		return "dead-letter"
+	}
+}
+
+// Decide settles a delivery whose handler returned err. Errors that are not
+// retryable are dead-lettered at once. Retryable errors are requeued once,
+// and dead-lettered if the redelivery fails too.
+func Decide(err error, redelivered bool) Decision {
+	switch {
+	case err == nil:
+		return Ack
+	case IsRetryable(err) && !redelivered:
+		return Requeue
+	default:
+		return DeadLetter
+	}
+}
+
+// Settle acknowledges or rejects d according to Decide and returns the
+// decision taken.
+func Settle(d amqp.Delivery, err error) (Decision, error) {
+	decision := Decide(err, d.Redelivered)
+	switch decision {
+	case Ack:
+		return decision, d.Ack(false)
+	case Requeue:
+		return decision, d.Reject(true)
+	default:
+		return decision, d.Reject(false)
+	}
+}
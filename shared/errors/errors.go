@@ -0,0 +1,170 @@
+// Package errors defines the application errors shared by the HTTP, gRPC and
+// messaging layers. An Error carries a Code that each transport maps to its
+// own status, a message that is safe to show to clients, optional details and
+// whether retrying may succeed.
+package errors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type Code string
+
+const (
+	CodeInvalidArgument  Code = "invalid_argument"
+	CodeUnauthenticated  Code = "unauthenticated"
+	CodePermissionDenied Code = "permission_denied"
+	CodeNotFound         Code = "not_found"
+	CodeConflict         Code = "conflict"
+	CodeRateLimited      Code = "rate_limited"
+	CodeCanceled         Code = "canceled"
+	CodeDeadlineExceeded Code = "deadline_exceeded"
+	CodeUnavailable      Code = "unavailable"
+	CodeBadGateway       Code = "bad_gateway"
+	CodeInternal         Code = "internal"
+)
+
+// retryable lists the codes whose errors are retryable by default.
+var retryable = map[Code]bool{
+	CodeRateLimited:      true,
+	CodeDeadlineExceeded: true,
+	CodeUnavailable:      true,
+	CodeBadGateway:       true,
+	CodeInternal:         true,
+}
+
+// FieldViolation describes one invalid field of a request.
+type FieldViolation struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
+}
+
+type Error struct {
+	Code Code
+	// Message is shown to clients. Internal errors hide it.
+	Message string
+	// Fields lists invalid request fields.
+	Fields []FieldViolation
+	// Metadata holds further machine-readable details.
+	Metadata map[string]string
+	// Retryable reports whether the same request may succeed later.
+	Retryable bool
+	// RetryAfter is how long clients should wait before retrying, if known.
+	RetryAfter time.Duration
+	// Err is the underlying cause. It is logged but never sent to clients.
+	Err error
+}
+
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message, Retryable: retryable[code]}
+}
+
+func Newf(code Code, format string, args ...interface{}) *Error {
+	return New(code, fmt.Sprintf(format, args...))
+}
+
+// Wrap returns an error with code and message caused by err.
+func Wrap(err error, code Code, message string) *Error {
+	e := New(code, message)
+	e.Err = err
+	return e
+}
+
+func InvalidArgument(message string, fields ...FieldViolation) *Error {
+	e := New(CodeInvalidArgument, message)
+	e.Fields = fields
+	return e
+}
+
+func NotFound(message string) *Error {
+	return New(CodeNotFound, message)
+}
+
+func Conflict(message string) *Error {
+	return New(CodeConflict, message)
+}
+
+func Unavailable(message string) *Error {
+	return New(CodeUnavailable, message)
+}
+
+func RateLimited(message string, retryAfter time.Duration) *Error {
+	e := New(CodeRateLimited, message)
+	e.RetryAfter = retryAfter
+	return e
+}
+
+// Internal wraps an unexpected error. Clients only see a generic message.
+func Internal(err error) *Error {
+	return Wrap(err, CodeInternal, "internal error")
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.Err)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// WithMetadata returns a copy of e with key set in its metadata.
+func (e *Error) WithMetadata(key, value string) *Error {
+	c := *e
+	c.Metadata = make(map[string]string, len(e.Metadata)+1)
+	for k, v := range e.Metadata {
+		c.Metadata[k] = v
+	}
+	c.Metadata[key] = value
+	return &c
+}
+
+// From returns err as an *Error. Context errors get their own codes, gRPC
+// status errors are converted back with FromGRPC and anything else becomes an
+// internal error.
+func From(err error) *Error {
+	var e *Error
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &e):
+		return e
+	case errors.Is(err, context.Canceled):
+		return Wrap(err, CodeCanceled, "request canceled")
+	case errors.Is(err, context.DeadlineExceeded):
+		return Wrap(err, CodeDeadlineExceeded, "deadline exceeded")
+	}
+	if e := FromGRPC(err); e != nil {
+		return e
+	}
+	return Internal(err)
+}
+
+// CodeOf returns the code of err, CodeInternal for untyped errors and the
+// empty code for nil.
+func CodeOf(err error) Code {
+	if err == nil {
+		return ""
+	}
+	return From(err).Code
+}
+
+// HasCode reports whether err is an *Error with the given code.
+func HasCode(err error, code Code) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Code == code
+}
+
+// IsRetryable reports whether retrying the operation that failed with err may
+// succeed. Untyped errors are assumed to be transient.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	return From(err).Retryable
+}
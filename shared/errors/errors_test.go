@@ -0,0 +1,93 @@
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestFrom(t *testing.T) {
+	notFound := NotFound("item not found")
+	assert.Same(t, notFound, From(fmt.Errorf("lookup: %w", notFound)))
+	assert.Equal(t, CodeDeadlineExceeded, CodeOf(context.DeadlineExceeded))
+	assert.Equal(t, CodeNotFound, CodeOf(status.Error(codes.NotFound, "gone")))
+
+	internal := From(fmt.Errorf("connection reset"))
+	assert.Equal(t, CodeInternal, internal.Code)
+	assert.Equal(t, "internal error", internal.Message)
+	assert.True(t, internal.Retryable)
+	assert.False(t, IsRetryable(notFound))
+}
+
+func TestWriteProblem(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/items", func(c *gin.Context) {
+		WriteProblem(c, InvalidArgument("name is required", FieldViolation{Field: "name", Description: "is required"}))
+	})
+	r.GET("/limited", func(c *gin.Context) {
+		WriteProblem(c, RateLimited("slow down", 1500*time.Millisecond))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/items", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, ContentTypeProblem, w.Header().Get("Content-Type"))
+	var problem Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, Problem{
+		Type:     "about:blank",
+		Title:    "Bad Request",
+		Status:   http.StatusBadRequest,
+		Detail:   "name is required",
+		Instance: "/items",
+		Code:     CodeInvalidArgument,
+		Errors:   []FieldViolation{{Field: "name", Description: "is required"}},
+	}, problem)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/limited", nil))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+}
+
+func TestGRPCRoundTrip(t *testing.T) {
+	e := InvalidArgument("quantity must not be negative", FieldViolation{Field: "quantity", Description: "must not be negative"}).
+		WithMetadata("item", "42")
+
+	st := status.Convert(e)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	assert.Len(t, st.Details(), 2)
+
+	back := FromGRPC(st.Err())
+	assert.Equal(t, CodeInvalidArgument, back.Code)
+	assert.Equal(t, e.Message, back.Message)
+	assert.Equal(t, e.Fields, back.Fields)
+	assert.Equal(t, map[string]string{"item": "42"}, back.Metadata)
+
+	limited := FromGRPC(status.Convert(RateLimited("slow down", time.Second)).Err())
+	assert.Equal(t, time.Second, limited.RetryAfter)
+	assert.True(t, limited.Retryable)
+
+	// Statuses without details still map by code
+	assert.Equal(t, CodeUnavailable, FromGRPC(status.Error(codes.Unavailable, "down")).Code)
+	assert.Nil(t, FromGRPC(fmt.Errorf("plain")))
+}
+
+func TestDecide(t *testing.T) {
+	assert.Equal(t, Ack, Decide(nil, false))
+	assert.Equal(t, DeadLetter, Decide(InvalidArgument("bad payload"), false))
+	assert.Equal(t, Requeue, Decide(Unavailable("database down"), false))
+	assert.Equal(t, DeadLetter, Decide(Unavailable("database down"), true))
+	assert.Equal(t, Requeue, Decide(fmt.Errorf("unexpected"), false))
+}
@@ -0,0 +1,109 @@
+package errors
+
+import (
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Domain is reported in the ErrorInfo detail of gRPC errors.
+const Domain = "microkit"
+
+var grpcCodes = map[Code]codes.Code{
+	CodeInvalidArgument:  codes.InvalidArgument,
+	CodeUnauthenticated:  codes.Unauthenticated,
+	CodePermissionDenied: codes.PermissionDenied,
+	CodeNotFound:         codes.NotFound,
+	CodeConflict:         codes.Aborted,
+	CodeRateLimited:      codes.ResourceExhausted,
+	CodeCanceled:         codes.Canceled,
+	CodeDeadlineExceeded: codes.DeadlineExceeded,
+	CodeUnavailable:      codes.Unavailable,
+	CodeBadGateway:       codes.Unavailable,
+	CodeInternal:         codes.Internal,
+}
+
+// GRPCCode returns the gRPC status code for e.
+func (e *Error) GRPCCode() codes.Code {
+	if code, ok := grpcCodes[e.Code]; ok {
+		return code
+	}
+	return codes.Internal
+}
+
+// GRPCStatus converts e to a status with ErrorInfo, BadRequest and RetryInfo
+// details. gRPC calls it when a handler returns e, so handlers can return
+// *Error directly.
+func (e *Error) GRPCStatus() *status.Status {
+	st := status.New(e.GRPCCode(), e.Message)
+
+	metadata := map[string]string{}
+	for k, v := range e.Metadata {
+		metadata[k] = v
+	}
+	if e.Retryable {
+		metadata["retryable"] = "true"
+	}
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: string(e.Code), Domain: Domain, Metadata: metadata}}
+	if len(e.Fields) > 0 {
+		br := &errdetails.BadRequest{}
+		for _, f := range e.Fields {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{Field: f.Field, Description: f.Description})
+		}
+		details = append(details, br)
+	}
+	if e.RetryAfter > 0 {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(e.RetryAfter)})
+	}
+
+	withDetails, err := st.WithDetails(details...)
+	if err != nil {
+		return st
+	}
+	return withDetails
+}
+
+// FromGRPC converts a gRPC status error, e.g. one returned by a client call,
+// back to an *Error. It returns nil if err carries no status.
+func FromGRPC(err error) *Error {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() == codes.OK {
+		return nil
+	}
+
+	e := &Error{Code: CodeInternal, Message: st.Message(), Err: err}
+	for code, grpcCode := range grpcCodes {
+		// Unavailable maps back to CodeUnavailable, not CodeBadGateway
+		if grpcCode == st.Code() && code != CodeBadGateway {
+			e.Code = code
+			break
+		}
+	}
+	e.Retryable = retryable[e.Code]
+
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			e.Code = Code(d.Reason)
+			e.Retryable = d.Metadata["retryable"] == "true"
+			for k, v := range d.Metadata {
+				if k == "retryable" {
+					continue
+				}
+				if e.Metadata == nil {
+					e.Metadata = map[string]string{}
+				}
+				e.Metadata[k] = v
+			}
+		case *errdetails.BadRequest:
+			for _, f := range d.FieldViolations {
+				e.Fields = append(e.Fields, FieldViolation{Field: f.Field, Description: f.Description})
+			}
+		case *errdetails.RetryInfo:
+			e.RetryAfter = d.RetryDelay.AsDuration()
+		}
+	}
+	return e
+}
@@ -0,0 +1,74 @@
+package errors
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const ContentTypeProblem = "application/problem+json"
+
+var httpStatus = map[Code]int{
+	CodeInvalidArgument:  http.StatusBadRequest,
+	CodeUnauthenticated:  http.StatusUnauthorized,
+	CodePermissionDenied: http.StatusForbidden,
+	CodeNotFound:         http.StatusNotFound,
+	CodeConflict:         http.StatusConflict,
+	CodeRateLimited:      http.StatusTooManyRequests,
+	CodeCanceled:         499,
+	CodeDeadlineExceeded: http.StatusGatewayTimeout,
+	CodeUnavailable:      http.StatusServiceUnavailable,
+	CodeBadGateway:       http.StatusBadGateway,
+	CodeInternal:         http.StatusInternalServerError,
+}
+
+// HTTPStatus returns the HTTP status code for e.
+func (e *Error) HTTPStatus() int {
+	if status, ok := httpStatus[e.Code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Problem is an RFC 7807 problem details document. The type is always
+// about:blank, so the title is the HTTP status text; Code tells errors apart.
+type Problem struct {
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Status    int               `json:"status"`
+	Detail    string            `json:"detail,omitempty"`
+	Instance  string            `json:"instance,omitempty"`
+	Code      Code              `json:"code"`
+	Retryable bool              `json:"retryable"`
+	Errors    []FieldViolation  `json:"errors,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+// Problem converts e to problem details for the request path instance.
+func (e *Error) Problem(instance string) Problem {
+	status := e.HTTPStatus()
+	return Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    e.Message,
+		Instance:  instance,
+		Code:      e.Code,
+		Retryable: e.Retryable,
+		Errors:    e.Fields,
+		Metadata:  e.Metadata,
+	}
+}
+
+// WriteProblem aborts the request with err as a problem+json response. A
+// Retry-After header is added when the error says when to retry.
+func WriteProblem(c *gin.Context, err error) {
+	e := From(err)
+	if e.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(e.RetryAfter.Seconds()))))
+	}
+	c.Header("Content-Type", ContentTypeProblem)
+	c.AbortWithStatusJSON(e.HTTPStatus(), e.Problem(c.Request.URL.Path))
+}
@@ -0,0 +1,142 @@
+package grpcserver
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	apperrors "github.com/MuxSphere/microkit/shared/errors"
+	"github.com/MuxSphere/microkit/shared/validation"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/keepalive"
+)
+
+// New returns a server whose unary and streaming calls go through the same
+// error, logging, recovery and validation interceptors. Keepalive pings detect clients
+// that vanished without closing the connection, which cancels their streams.
+func New(logger *zap.Logger, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append([]grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(UnaryErrors(), UnaryLogging(logger), UnaryRecovery(logger), validation.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(StreamErrors(), StreamLogging(logger), StreamRecovery(logger), validation.StreamServerInterceptor()),
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:    2 * time.Minute,
+			Timeout: 20 * time.Second,
+		}),
+	}, opts...)
+	return grpc.NewServer(opts...)
+}
+
+// UnaryErrors converts handler errors to statuses through apperrors, so
+// unexpected errors reach clients as Internal without their cause.
+func UnaryErrors() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		return resp, toStatus(err)
+	}
+}
+
+// StreamErrors is the streaming counterpart of UnaryErrors.
+func StreamErrors() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return toStatus(handler(srv, ss))
+	}
+}
+
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	return apperrors.From(err).GRPCStatus().Err()
+}
+
+// UnaryRecovery turns a panicking handler into an Internal error.
+func UnaryRecovery(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer recoverPanic(logger, info.FullMethod, &err)
+		return handler(ctx, req)
+	}
+}
+
+// StreamRecovery turns a panicking stream handler into an Internal error.
+func StreamRecovery(logger *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer recoverPanic(logger, info.FullMethod, &err)
+		return handler(srv, ss)
+	}
+}
+
+func recoverPanic(logger *zap.Logger, method string, err *error) {
+	if r := recover(); r != nil {
+		logger.Error("Recovered from panic in gRPC handler",
+			zap.String("method", method),
+			zap.Any("panic", r),
+			zap.ByteString("stack", debug.Stack()),
+		)
+		*err = apperrors.Internal(fmt.Errorf("panic: %v", r))
+	}
+}
+
+// UnaryLogging logs every call with its status code and latency. Errors are
+// logged with their cause.
+func UnaryLogging(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logCall(logger, info.FullMethod, err, zap.Duration("latency", time.Since(start)))
+		return resp, err
+	}
+}
+
+// StreamLogging logs every stream when it ends, with the number of messages
+// received and sent.
+func StreamLogging(logger *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		counted := &countingStream{ServerStream: ss}
+		err := handler(srv, counted)
+		logCall(logger, info.FullMethod, err,
+			zap.Duration("latency", time.Since(start)),
+			zap.Int("received", counted.received),
+			zap.Int("sent", counted.sent),
+		)
+		return err
+	}
+}
+
+func logCall(logger *zap.Logger, method string, err error, fields ...zap.Field) {
+	code := codes.OK
+	if err != nil {
+		code = apperrors.From(err).GRPCCode()
+	}
+	fields = append([]zap.Field{zap.String("method", method), zap.String("code", code.String())}, fields...)
+	switch code {
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unimplemented:
+		logger.Error(method, append(fields, zap.Error(err))...)
+	default:
+		logger.Info(method, fields...)
+	}
+}
+
+type countingStream struct {
+	grpc.ServerStream
+	received, sent int
+}
+
+func (s *countingStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.received++
+	}
+	return err
+}
+
+func (s *countingStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.sent++
+	}
+	return err
+}
@@ -0,0 +1,58 @@
+package grpcserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeStream struct {
+	grpc.ServerStream
+	msgs []string
+}
+
+func (s *fakeStream) Context() context.Context { return context.Background() }
+
+func (s *fakeStream) SendMsg(m interface{}) error {
+	s.msgs = append(s.msgs, m.(string))
+	return nil
+}
+
+func TestRecoveryTurnsPanicsIntoInternal(t *testing.T) {
+	logger := zap.NewNop()
+
+	_, err := UnaryRecovery(logger)(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/test/Unary"},
+		func(ctx context.Context, req interface{}) (interface{}, error) { panic("boom") })
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	err = StreamRecovery(logger)(nil, &fakeStream{}, &grpc.StreamServerInfo{FullMethod: "/test/Stream"},
+		func(srv interface{}, ss grpc.ServerStream) error { panic("boom") })
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
+
+func TestStreamLoggingCountsMessages(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	stream := &fakeStream{}
+
+	err := StreamLogging(zap.New(core))(nil, stream, &grpc.StreamServerInfo{FullMethod: "/test/Stream"},
+		func(srv interface{}, ss grpc.ServerStream) error {
+			require.NoError(t, ss.SendMsg("a"))
+			require.NoError(t, ss.SendMsg("b"))
+			return status.Error(codes.NotFound, "gone")
+		})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, []string{"a", "b"}, stream.msgs)
+
+	require.Equal(t, 1, logs.Len())
+	fields := logs.All()[0].ContextMap()
+	assert.Equal(t, "NotFound", fields["code"])
+	assert.Equal(t, int64(2), fields["sent"])
+}
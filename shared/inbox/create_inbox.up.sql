@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS inbox (
+	consumer     TEXT NOT NULL,
+	message_id   TEXT NOT NULL,
+	processed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	PRIMARY KEY (consumer, message_id)
+);
+CREATE INDEX IF NOT EXISTS inbox_processed_at_idx ON inbox (processed_at);
@@ -0,0 +1,150 @@
+package inbox
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"time"
+
+	"github.com/MuxSphere/microkit/shared/database"
+	"github.com/MuxSphere/microkit/shared/rabbitmq"
+	"github.com/jmoiron/sqlx"
+	"github.com/streadway/amqp"
+	"go.uber.org/zap"
+)
+
+// ErrNoMessageID is returned for deliveries that cannot be deduplicated
+// because the publisher did not set a message ID.
+var ErrNoMessageID = errors.New("inbox: delivery has no message ID")
+
+var (
+	//go:embed create_inbox.up.sql
+	createUp string
+	//go:embed create_inbox.down.sql
+	createDown string
+)
+
+// Migration returns the migration creating the inbox table, numbered version
+// so that it fits between a service's own migrations:
+//
+//	schema, _ := database.LoadMigrations(migrations.FS, ".")
+//	schema = append(schema, inbox.Migration(3))
+//
+// Every service that consumes through an Inbox must include it.
+func Migration(version int64) database.Migration {
+	return database.Migration{Version: version, Name: "create_inbox", Up: createUp, Down: createDown}
+}
+
+// Inbox deduplicates redelivered messages for one consumer.
+type Inbox struct {
+	db       database.DB
+	consumer string
+	logger   *zap.Logger
+
+	// Retention is how long processed message IDs are remembered. A message
+	// redelivered after that is processed again.
+	Retention time.Duration
+}
+
+func New(db database.DB, consumer string, logger *zap.Logger) *Inbox {
+	return &Inbox{
+		db:        db,
+		consumer:  consumer,
+		logger:    logger,
+		Retention: 7 * 24 * time.Hour,
+	}
+}
+
+// Process runs fn in a transaction that also records messageID. If messageID
+// was already recorded, fn is skipped and Process reports a duplicate.
+func (in *Inbox) Process(ctx context.Context, messageID string, fn func(tx *sqlx.Tx) error) (duplicate bool, err error) {
+	tx, err := in.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// A concurrent delivery of the same message blocks here until the first
+	// transaction finishes, then sees the conflict.
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO inbox (consumer, message_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+		in.consumer, messageID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if n == 0 {
+		return true, nil
+	}
+
+	if err := fn(tx); err != nil {
+		return false, err
+	}
+	return false, tx.Commit()
+}
+
+// Handle wraps an event handler for rabbitmq.Subscribe so that each event ID
+// is handled at most once. The handler's writes share the inbox transaction.
+func Handle[T rabbitmq.Event](in *Inbox, handler func(ctx context.Context, tx *sqlx.Tx, env rabbitmq.Envelope, event T) error) func(context.Context, rabbitmq.Envelope, T) error {
+	return func(ctx context.Context, env rabbitmq.Envelope, event T) error {
+		duplicate, err := in.Process(ctx, env.ID, func(tx *sqlx.Tx) error {
+			return handler(ctx, tx, env, event)
+		})
+		if duplicate {
+			in.logger.Info("Skipping duplicate message", zap.String("consumer", in.consumer), zap.String("id", env.ID))
+		}
+		return err
+	}
+}
+
+// HandleDelivery wraps a handler for raw deliveries, e.g. from Consume, so
+// that each message ID is handled at most once. The handler's writes share the
+// inbox transaction. Deliveries without a message ID fail with ErrNoMessageID.
+func HandleDelivery(in *Inbox, handler func(ctx context.Context, tx *sqlx.Tx, d amqp.Delivery) error) func(context.Context, amqp.Delivery) error {
+	return func(ctx context.Context, d amqp.Delivery) error {
+		if d.MessageId == "" {
+			return ErrNoMessageID
+		}
+		duplicate, err := in.Process(ctx, d.MessageId, func(tx *sqlx.Tx) error {
+			return handler(ctx, tx, d)
+		})
+		if duplicate {
+			in.logger.Info("Skipping duplicate message", zap.String("consumer", in.consumer), zap.String("id", d.MessageId))
+		}
+		return err
+	}
+}
+
+// Cleanup forgets message IDs older than the retention window.
+func (in *Inbox) Cleanup(ctx context.Context) (int64, error) {
+	res, err := in.db.ExecContext(ctx,
+		`DELETE FROM inbox WHERE consumer = $1 AND processed_at < $2`,
+		in.consumer, time.Now().Add(-in.Retention))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// RunCleanup calls Cleanup every interval until ctx is cancelled.
+func (in *Inbox) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := in.Cleanup(ctx)
+			if err != nil {
+				in.logger.Error("Failed to clean up inbox", zap.String("consumer", in.consumer), zap.Error(err))
+				continue
+			}
+			in.logger.Debug("Cleaned up inbox", zap.String("consumer", in.consumer), zap.Int64("deleted", n))
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package inbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/MuxSphere/microkit/shared/database/databasetest"
+	"github.com/MuxSphere/microkit/shared/rabbitmq"
+	"github.com/jmoiron/sqlx"
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type itemCreated struct{}
+
+func (itemCreated) EventType() string { return "item.created" }
+
+const insertInbox = `INSERT INTO inbox \(consumer, message_id\) VALUES \(\$1, \$2\) ON CONFLICT DO NOTHING`
+
+func TestHandleSkipsProcessedMessages(t *testing.T) {
+	db, sqlMock, err := databasetest.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	in := New(db, "service-b.items", zap.NewNop())
+	calls := 0
+	handle := Handle(in, func(ctx context.Context, tx *sqlx.Tx, env rabbitmq.Envelope, event itemCreated) error {
+		calls++
+		_, err := tx.ExecContext(ctx, `UPDATE stock SET quantity = quantity + 1`)
+		return err
+	})
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(insertInbox).WithArgs("service-b.items", "m1").WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectExec(`UPDATE stock`).WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+	require.NoError(t, handle(context.Background(), rabbitmq.Envelope{ID: "m1"}, itemCreated{}))
+
+	// The redelivery conflicts with the recorded ID and is not handled again
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(insertInbox).WithArgs("service-b.items", "m1").WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectRollback()
+	require.NoError(t, handle(context.Background(), rabbitmq.Envelope{ID: "m1"}, itemCreated{}))
+
+	assert.Equal(t, 1, calls)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestHandleDelivery(t *testing.T) {
+	db, sqlMock, err := databasetest.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	in := New(db, "service-a.example", zap.NewNop())
+	var handled []string
+	handle := HandleDelivery(in, func(ctx context.Context, tx *sqlx.Tx, d amqp.Delivery) error {
+		handled = append(handled, string(d.Body))
+		return nil
+	})
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(insertInbox).WithArgs("service-a.example", "m1").WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(insertInbox).WithArgs("service-a.example", "m1").WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectRollback()
+
+	d := amqp.Delivery{MessageId: "m1", Body: []byte("first")}
+	require.NoError(t, handle(context.Background(), d))
+	d.Redelivered = true
+	d.Body = []byte("again")
+	require.NoError(t, handle(context.Background(), d))
+	assert.Equal(t, []string{"first"}, handled)
+
+	assert.ErrorIs(t, handle(context.Background(), amqp.Delivery{Body: []byte("anonymous")}), ErrNoMessageID)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestHandlerErrorRollsBackInboxRow(t *testing.T) {
+	db, sqlMock, err := databasetest.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	in := New(db, "service-a.example", zap.NewNop())
+	boom := errors.New("boom")
+	fail := true
+	handle := HandleDelivery(in, func(ctx context.Context, tx *sqlx.Tx, d amqp.Delivery) error {
+		if fail {
+			return boom
+		}
+		return nil
+	})
+
+	// The failed attempt rolls back the inbox row, so the retry is handled
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(insertInbox).WithArgs("service-a.example", "m1").WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectRollback()
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(insertInbox).WithArgs("service-a.example", "m1").WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+
+	assert.ErrorIs(t, handle(context.Background(), amqp.Delivery{MessageId: "m1"}), boom)
+	fail = false
+	assert.NoError(t, handle(context.Background(), amqp.Delivery{MessageId: "m1"}))
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestHandleDeliverySkipsRedeliveredMessages(t *testing.T) {
+	db, sqlMock, err := databasetest.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mq := rabbitmq.NewMemoryBroker(zap.NewNop())
+	defer mq.Close()
+	require.NoError(t, mq.DeclareQueue("example_queue", nil))
+	msgs, err := mq.Consume("example_queue", "test")
+	require.NoError(t, err)
+
+	in := New(db, "service-a.example", zap.NewNop())
+	var handled []string
+	handle := HandleDelivery(in, func(ctx context.Context, tx *sqlx.Tx, d amqp.Delivery) error {
+		handled = append(handled, string(d.Body))
+		return nil
+	})
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(insertInbox).WithArgs("service-a.example", "m1").WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(insertInbox).WithArgs("service-a.example", "m1").WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectRollback()
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(insertInbox).WithArgs("service-a.example", "m2").WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+
+	// The publisher sends m1 twice, e.g. after a lost confirm
+	for _, m := range []amqp.Publishing{
+		{MessageId: "m1", Body: []byte("first")},
+		{MessageId: "m1", Body: []byte("first again")},
+		{MessageId: "m2", Body: []byte("second")},
+	} {
+		require.NoError(t, mq.Publish(context.Background(), "", "example_queue", m))
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case d := <-msgs:
+			require.NoError(t, handle(context.Background(), d))
+			require.NoError(t, d.Ack(false))
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for delivery")
+		}
+	}
+
+	assert.Equal(t, []string{"first", "second"}, handled)
+	assert.Equal(t, 0, mq.Unacked())
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestMigration(t *testing.T) {
+	m := Migration(3)
+	assert.Equal(t, int64(3), m.Version)
+	assert.Contains(t, m.Up, "CREATE TABLE IF NOT EXISTS inbox")
+	assert.Contains(t, m.Down, "DROP TABLE IF EXISTS inbox")
+}
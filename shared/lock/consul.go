@@ -0,0 +1,74 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+)
+
+var _ Locker = (*ConsulLocker)(nil)
+
+// ConsulLocker holds locks as KV keys acquired with a Consul session. The
+// session is renewed in the background; if renewal fails for longer than
+// SessionTTL, Consul releases the key and the lease is lost.
+type ConsulLocker struct {
+	client *api.Client
+
+	// Prefix is prepended to every lock key.
+	Prefix string
+	// SessionTTL bounds how long a crashed holder keeps the lock.
+	SessionTTL time.Duration
+	// MonitorRetries is how many failed checks of the key are tolerated
+	// before the lease is considered lost.
+	MonitorRetries int
+}
+
+func NewConsulLocker(client *api.Client) *ConsulLocker {
+	return &ConsulLocker{
+		client:         client,
+		Prefix:         "microkit/locks/",
+		SessionTTL:     15 * time.Second,
+		MonitorRetries: 3,
+	}
+}
+
+func (c *ConsulLocker) Lock(ctx context.Context, key string) (*Lease, error) {
+	l, err := c.client.LockOpts(&api.LockOptions{
+		Key:            c.Prefix + key,
+		SessionName:    key,
+		SessionTTL:     c.SessionTTL.String(),
+		MonitorRetries: c.MonitorRetries,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Abort the attempt when ctx is done. stop is only read while acquiring.
+	stop := make(chan struct{})
+	acquired := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			close(stop)
+		case <-acquired:
+		}
+	}()
+	lost, err := l.Lock(stop)
+	close(acquired)
+	if err != nil {
+		return nil, err
+	}
+	if lost == nil {
+		return nil, ctx.Err()
+	}
+
+	return newLease(ctx, lost, func() error {
+		err := l.Unlock()
+		if errors.Is(err, api.ErrLockNotHeld) {
+			return ErrNotHeld
+		}
+		return err
+	}), nil
+}
@@ -0,0 +1,109 @@
+// Package lock provides distributed locks and leader election, so singleton
+// background jobs run on exactly one replica.
+package lock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ErrNotHeld is returned when releasing a lock that was already lost.
+var ErrNotHeld = errors.New("lock not held")
+
+// Locker acquires named locks shared by all replicas.
+type Locker interface {
+	// Lock blocks until key is acquired or ctx is done.
+	Lock(ctx context.Context, key string) (*Lease, error)
+}
+
+// Lease is a held lock. Its context is cancelled when the lock is lost, when
+// Unlock is called or when the context passed to Lock is done.
+type Lease struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	release func() error
+
+	once sync.Once
+	err  error
+}
+
+func newLease(parent context.Context, lost <-chan struct{}, release func() error) *Lease {
+	ctx, cancel := context.WithCancel(parent)
+	go func() {
+		select {
+		case <-lost:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return &Lease{ctx: ctx, cancel: cancel, release: release}
+}
+
+// Context returns a context to run the guarded work with.
+func (l *Lease) Context() context.Context {
+	return l.ctx
+}
+
+// Lost is closed once the lease ends for any reason.
+func (l *Lease) Lost() <-chan struct{} {
+	return l.ctx.Done()
+}
+
+// Unlock releases the lock. It is safe to call more than once.
+func (l *Lease) Unlock() error {
+	l.once.Do(func() {
+		l.cancel()
+		l.err = l.release()
+	})
+	return l.err
+}
+
+// Elector runs a function on whichever replica holds a lock, taking over when
+// the current leader stops or loses the lock.
+type Elector struct {
+	locker Locker
+	key    string
+	logger *zap.Logger
+
+	// RetryInterval is the wait after a failed acquisition attempt.
+	RetryInterval time.Duration
+}
+
+func NewElector(locker Locker, key string, logger *zap.Logger) *Elector {
+	return &Elector{
+		locker:        locker,
+		key:           key,
+		logger:        logger,
+		RetryInterval: 5 * time.Second,
+	}
+}
+
+// Run campaigns for leadership until ctx is cancelled and calls fn each time
+// it is won. fn must return when its context is done.
+func (e *Elector) Run(ctx context.Context, fn func(ctx context.Context)) {
+	for ctx.Err() == nil {
+		lease, err := e.locker.Lock(ctx, e.key)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			e.logger.Error("Failed to acquire leadership", zap.String("key", e.key), zap.Error(err))
+			select {
+			case <-ctx.Done():
+			case <-time.After(e.RetryInterval):
+			}
+			continue
+		}
+
+		e.logger.Info("Acquired leadership", zap.String("key", e.key))
+		fn(lease.Context())
+		if err := lease.Unlock(); err != nil && !errors.Is(err, ErrNotHeld) {
+			e.logger.Warn("Failed to release leadership", zap.String("key", e.key), zap.Error(err))
+		}
+		e.logger.Info("Released leadership", zap.String("key", e.key))
+	}
+}
@@ -0,0 +1,135 @@
+package lock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/MuxSphere/microkit/shared/database/databasetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestMemoryLockerExclusive(t *testing.T) {
+	m := NewMemoryLocker()
+
+	first, err := m.Lock(context.Background(), "relay")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = m.Lock(ctx, "relay")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	require.NoError(t, first.Unlock())
+	require.NoError(t, first.Unlock())
+	assert.Error(t, first.Context().Err())
+
+	second, err := m.Lock(context.Background(), "relay")
+	require.NoError(t, err)
+	m.Revoke("relay")
+	select {
+	case <-second.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("lease not lost after revoke")
+	}
+	assert.ErrorIs(t, second.Unlock(), ErrNotHeld)
+}
+
+func TestElectorFailover(t *testing.T) {
+	m := NewMemoryLocker()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	leaders := make(chan string, 4)
+	run := func(name string) {
+		e := NewElector(m, "relay", zap.NewNop())
+		e.Run(ctx, func(ctx context.Context) {
+			leaders <- name
+			<-ctx.Done()
+		})
+	}
+	go run("a")
+	go run("b")
+
+	var first string
+	select {
+	case first = <-leaders:
+	case <-time.After(time.Second):
+		t.Fatal("no leader elected")
+	}
+
+	// Only one replica leads until the lock is lost.
+	select {
+	case name := <-leaders:
+		t.Fatalf("%s elected while %s leads", name, first)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	m.Revoke("relay")
+	select {
+	case <-leaders:
+	case <-time.After(time.Second):
+		t.Fatal("no new leader after lock was lost")
+	}
+}
+
+func TestPostgresLocker(t *testing.T) {
+	db, mock, err := databasetest.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	id := advisoryKey("relay")
+	mock.ExpectQuery(`SELECT pg_try_advisory_lock`).WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"ok"}).AddRow(false))
+	mock.ExpectQuery(`SELECT pg_try_advisory_lock`).WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"ok"}).AddRow(true))
+	mock.ExpectQuery(`SELECT pg_advisory_unlock`).WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"ok"}).AddRow(true))
+
+	p := NewPostgresLocker(db)
+	p.RetryInterval = 10 * time.Millisecond
+	lease, err := p.Lock(context.Background(), "relay")
+	require.NoError(t, err)
+	require.NoError(t, lease.Unlock())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresLockerDiscardsUnresponsiveConnection(t *testing.T) {
+	db, mock, err := databasetest.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	id := advisoryKey("relay")
+	mock.ExpectQuery(`SELECT pg_try_advisory_lock`).WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"ok"}).AddRow(true))
+	mock.ExpectExec(`SELECT 1`).WillDelayFor(time.Minute).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT pg_advisory_unlock`).WithArgs(id).WillDelayFor(time.Minute).
+		WillReturnRows(sqlmock.NewRows([]string{"ok"}).AddRow(true))
+	mock.ExpectClose()
+
+	p := NewPostgresLocker(db)
+	p.CheckInterval = 20 * time.Millisecond
+	lease, err := p.Lock(context.Background(), "relay")
+	require.NoError(t, err)
+
+	// A hung check ends the lease, and the unlock gives up instead of hanging
+	select {
+	case <-lease.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("lease not lost after check timed out")
+	}
+	unlocked := make(chan error, 1)
+	go func() { unlocked <- lease.Unlock() }()
+	select {
+	case err := <-unlocked:
+		assert.Error(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("unlock did not return")
+	}
+
+	// The connection may still hold the lock, so it is closed, not pooled
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,62 @@
+package lock
+
+import (
+	"context"
+	"sync"
+)
+
+var _ Locker = (*MemoryLocker)(nil)
+
+// MemoryLocker holds locks in process. It is meant for tests and single
+// replica runs.
+type MemoryLocker struct {
+	mu      sync.Mutex
+	holders map[string]chan struct{}
+}
+
+func NewMemoryLocker() *MemoryLocker {
+	return &MemoryLocker{holders: make(map[string]chan struct{})}
+}
+
+func (m *MemoryLocker) Lock(ctx context.Context, key string) (*Lease, error) {
+	for {
+		m.mu.Lock()
+		held, ok := m.holders[key]
+		if !ok {
+			lost := make(chan struct{})
+			m.holders[key] = lost
+			m.mu.Unlock()
+			return newLease(ctx, lost, func() error { return m.release(key, lost) }), nil
+		}
+		m.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-held:
+		}
+	}
+}
+
+// Revoke takes key away from its holder, as if its session had expired.
+func (m *MemoryLocker) Revoke(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if lost, ok := m.holders[key]; ok {
+		close(lost)
+		delete(m.holders, key)
+	}
+}
+
+func (m *MemoryLocker) release(key string, lost chan struct{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.holders[key] != lost {
+		return ErrNotHeld
+	}
+	close(lost)
+	delete(m.holders, key)
+	return nil
+}
@@ -0,0 +1,118 @@
+package lock
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"hash/fnv"
+	"time"
+)
+
+var _ Locker = (*PostgresLocker)(nil)
+
+// Conner opens dedicated connections. It is implemented by *sql.DB and
+// *sqlx.DB.
+type Conner interface {
+	Conn(ctx context.Context) (*sql.Conn, error)
+}
+
+// PostgresLocker holds locks as session-level advisory locks for deployments
+// without Consul. Each lease pins one pooled connection; the lock is lost if
+// that connection breaks.
+type PostgresLocker struct {
+	db Conner
+
+	// RetryInterval is the wait between acquisition attempts.
+	RetryInterval time.Duration
+	// CheckInterval is the wait between checks that the holding connection
+	// is still alive. It also bounds each check and the unlock query.
+	CheckInterval time.Duration
+}
+
+func NewPostgresLocker(db Conner) *PostgresLocker {
+	return &PostgresLocker{
+		db:            db,
+		RetryInterval: 2 * time.Second,
+		CheckInterval: 5 * time.Second,
+	}
+}
+
+func (p *PostgresLocker) Lock(ctx context.Context, key string) (*Lease, error) {
+	conn, err := p.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	id := advisoryKey(key)
+	for {
+		var ok bool
+		if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, id).Scan(&ok); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		if ok {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			conn.Close()
+			return nil, ctx.Err()
+		case <-time.After(p.RetryInterval):
+		}
+	}
+
+	lost := make(chan struct{})
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(p.CheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := p.check(conn); err != nil {
+					close(lost)
+					return
+				}
+			}
+		}
+	}()
+
+	return newLease(ctx, lost, func() error {
+		close(stop)
+		<-done
+		defer conn.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), p.CheckInterval)
+		defer cancel()
+		var ok bool
+		if err := conn.QueryRowContext(ctx, `SELECT pg_advisory_unlock($1)`, id).Scan(&ok); err != nil {
+			// The session may still hold the lock, so it must not go back
+			// to the pool.
+			conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+			return err
+		}
+		if !ok {
+			return ErrNotHeld
+		}
+		return nil
+	}), nil
+}
+
+// check reports whether conn still answers within CheckInterval.
+func (p *PostgresLocker) check(conn *sql.Conn) error {
+	ctx, cancel := context.WithTimeout(context.Background(), p.CheckInterval)
+	defer cancel()
+	_, err := conn.ExecContext(ctx, `SELECT 1`)
+	return err
+}
+
+// advisoryKey maps a lock name onto the 64-bit key space of advisory locks.
+func advisoryKey(key string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return int64(h.Sum64())
+}
@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	apperrors "github.com/MuxSphere/microkit/shared/errors"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// DebugHeader carries a token that turns on debug logging for one request.
+const DebugHeader = "X-Debug-Log"
+
+// LevelPath serves the runtime log level.
+const LevelPath = "/admin/log-level"
+
+// SignDebugToken returns a DebugHeader value that is valid until expires. A
+// token is the expiry in Unix seconds and its HMAC-SHA256 under key, joined by
+// a dot.
+func SignDebugToken(key []byte, expires time.Time) string {
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	return exp + "." + sign(key, exp)
+}
+
+// VerifyDebugToken reports whether token was signed with key and has not
+// expired at now.
+func VerifyDebugToken(key []byte, token string, now time.Time) bool {
+	exp, sig, ok := strings.Cut(token, ".")
+	if !ok || len(key) == 0 {
+		return false
+	}
+	if !hmac.Equal([]byte(sig), []byte(sign(key, exp))) {
+		return false
+	}
+	unix, err := strconv.ParseInt(exp, 10, 64)
+	return err == nil && now.Before(time.Unix(unix, 0))
+}
+
+func sign(key []byte, exp string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(exp))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// DebugRequests returns middleware that logs requests carrying a valid
+// DebugHeader token at debug level, whatever the service's level. Handlers
+// get the request logger with FromContext. With an empty key the header is
+// ignored.
+func DebugRequests(logger *zap.Logger, key []byte) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.GetHeader(DebugHeader)
+		if token == "" || len(key) == 0 {
+			c.Next()
+			return
+		}
+		if !VerifyDebugToken(key, token, time.Now()) {
+			// Any client can send a token, so this must not flood the logs
+			logger.Debug("Ignoring invalid debug token", zap.String("path", c.Request.URL.Path))
+			c.Next()
+			return
+		}
+		debug := Debug(logger).With(zap.Bool("debug_request", true))
+		c.Request = c.Request.WithContext(WithContext(c.Request.Context(), debug))
+		c.Next()
+	}
+}
+
+// RegisterLevelRoutes serves level on LevelPath. GET returns {"level":"info"}
+// and PUT with the same body changes it. Requests must send token as a bearer
+// token; with an empty token the routes are not registered. Register them
+// after any rate limiting middleware, so that guesses at the token are limited.
+func RegisterLevelRoutes(r gin.IRoutes, level zap.AtomicLevel, token string) {
+	if token == "" {
+		return
+	}
+	handler := func(c *gin.Context) {
+		got, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			apperrors.WriteProblem(c, apperrors.New(apperrors.CodeUnauthenticated, "Invalid admin token"))
+			return
+		}
+		level.ServeHTTP(c.Writer, c.Request)
+	}
+	r.GET(LevelPath, handler)
+	r.PUT(LevelPath, handler)
+}
@@ -1,18 +1,32 @@
 package logger
 
 import (
+	"context"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
-func New(logLevel string) *zap.Logger {
-	level := zap.NewAtomicLevel()
-	level.UnmarshalText([]byte(logLevel))
+// New returns a logger at logLevel, e.g. "info", and the handle that changes
+// its level at runtime. Unknown levels are an error.
+func New(logLevel string) (*zap.Logger, zap.AtomicLevel, error) {
+	level, err := zap.ParseAtomicLevel(logLevel)
+	if err != nil {
+		return nil, level, err
+	}
+	logger, err := NewWithLevel(level)
+	return logger, level, err
+}
 
+// NewWithLevel returns a logger whose level can be changed at runtime through
+// level.
+func NewWithLevel(level zap.AtomicLevel) (*zap.Logger, error) {
+	// The output accepts every level so that Debug can bypass level for
+	// single requests
 	config := zap.Config{
-		Level:       level,
+		Level:       zap.NewAtomicLevelAt(zap.DebugLevel),
 		Development: false,
 		Sampling: &zap.SamplingConfig{
 			Initial:    100,
@@ -24,8 +38,56 @@ func New(logLevel string) *zap.Logger {
 		ErrorOutputPaths: []string{"stderr"},
 	}
 
-	logger, _ := config.Build()
-	return logger
+	return config.Build(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &levelCore{Core: core, level: level}
+	}))
+}
+
+// levelCore drops entries below level before they reach the wrapped core.
+type levelCore struct {
+	zapcore.Core
+	level zapcore.LevelEnabler
+}
+
+func (c *levelCore) Enabled(l zapcore.Level) bool {
+	return c.level.Enabled(l)
+}
+
+func (c *levelCore) Check(e zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.level.Enabled(e.Level) {
+		return ce
+	}
+	return c.Core.Check(e, ce)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), level: c.level}
+}
+
+// Debug returns logger with every level enabled, whatever its runtime level.
+// Loggers not built by this package are returned unchanged.
+func Debug(logger *zap.Logger) *zap.Logger {
+	return logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		if c, ok := core.(*levelCore); ok {
+			return c.Core
+		}
+		return core
+	}))
+}
+
+type contextKey struct{}
+
+// WithContext returns a copy of ctx carrying logger.
+func WithContext(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the request logger stored in ctx, or fallback.
+func FromContext(ctx context.Context, fallback *zap.Logger) *zap.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*zap.Logger); ok {
+		return logger
+	}
+	return fallback
 }
 
 func GinMiddleware(logger *zap.Logger) gin.HandlerFunc {
@@ -36,6 +98,7 @@ func GinMiddleware(logger *zap.Logger) gin.HandlerFunc {
 
 		c.Next()
 
+		logger := FromContext(c.Request.Context(), logger)
 		end := time.Now()
 		latency := end.Sub(start)
 
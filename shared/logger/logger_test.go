@@ -0,0 +1,147 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newObserved(level zap.AtomicLevel) (*zap.Logger, *observer.ObservedLogs) {
+	core, logs := observer.New(zap.DebugLevel)
+	return zap.New(&levelCore{Core: core, level: level}), logs
+}
+
+func TestNew(t *testing.T) {
+	l, level, err := New("warn")
+	require.NoError(t, err)
+	assert.Equal(t, zap.WarnLevel, level.Level())
+	assert.False(t, l.Core().Enabled(zap.InfoLevel))
+
+	_, _, err = New("verbose")
+	assert.Error(t, err)
+}
+
+func TestLevelChangesAndDebug(t *testing.T) {
+	level := zap.NewAtomicLevelAt(zap.InfoLevel)
+	l, logs := newObserved(level)
+
+	l.Debug("hidden")
+	l.With(zap.String("k", "v")).Debug("hidden")
+	Debug(l).With(zap.String("k", "v")).Debug("shown")
+	level.SetLevel(zap.DebugLevel)
+	l.Debug("shown after change")
+
+	var messages []string
+	for _, e := range logs.All() {
+		messages = append(messages, e.Message)
+	}
+	assert.Equal(t, []string{"shown", "shown after change"}, messages)
+}
+
+func TestFromContext(t *testing.T) {
+	fallback := zap.NewNop()
+	assert.Same(t, fallback, FromContext(context.Background(), fallback))
+
+	l := zap.NewExample()
+	assert.Same(t, l, FromContext(WithContext(context.Background(), l), fallback))
+}
+
+func TestDebugToken(t *testing.T) {
+	key := []byte("debug-key")
+	now := time.Now()
+	token := SignDebugToken(key, now.Add(time.Minute))
+
+	assert.True(t, VerifyDebugToken(key, token, now))
+	assert.False(t, VerifyDebugToken(key, token, now.Add(2*time.Minute)))
+	assert.False(t, VerifyDebugToken([]byte("other"), token, now))
+	assert.False(t, VerifyDebugToken(nil, token, now))
+	assert.False(t, VerifyDebugToken(key, "garbage", now))
+
+	exp, sig, _ := strings.Cut(token, ".")
+	assert.False(t, VerifyDebugToken(key, exp+"0."+sig, now))
+}
+
+func TestDebugRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	key := []byte("debug-key")
+	l, logs := newObserved(zap.NewAtomicLevelAt(zap.InfoLevel))
+
+	r := gin.New()
+	r.Use(DebugRequests(l, key))
+	r.GET("/", func(c *gin.Context) {
+		FromContext(c.Request.Context(), l).Debug("handling")
+		c.Status(http.StatusNoContent)
+	})
+
+	for _, token := range []string{"", "bad.token", SignDebugToken(key, time.Now().Add(time.Minute))} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if token != "" {
+			req.Header.Set(DebugHeader, token)
+		}
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// The invalid token is only logged at debug level
+	entries := logs.All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "handling", entries[0].Message)
+	assert.Equal(t, true, entries[0].ContextMap()["debug_request"])
+}
+
+func TestRegisterLevelRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	level := zap.NewAtomicLevelAt(zap.InfoLevel)
+	r := gin.New()
+	RegisterLevelRoutes(r, level, "admin-token")
+
+	do := func(method, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, LevelPath, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPut, "wrong", `{"level":"debug"}`)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, zap.InfoLevel, level.Level())
+
+	// The token must be sent with the Bearer scheme
+	req := httptest.NewRequest(http.MethodPut, LevelPath, strings.NewReader(`{"level":"debug"}`))
+	req.Header.Set("Authorization", "admin-token")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, zap.InfoLevel, level.Level())
+
+	w = do(http.MethodPut, "admin-token", `{"level":"debug"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, zap.DebugLevel, level.Level())
+
+	w = do(http.MethodGet, "admin-token", "")
+	assert.JSONEq(t, `{"level":"debug"}`, w.Body.String())
+
+	w = do(http.MethodPut, "admin-token", `{"level":"verbose"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, zapcore.DebugLevel, level.Level())
+
+	// Without a token the endpoint does not exist
+	r = gin.New()
+	RegisterLevelRoutes(r, level, "")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, LevelPath, nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
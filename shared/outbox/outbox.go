@@ -0,0 +1,169 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/MuxSphere/microkit/shared/database"
+	"github.com/MuxSphere/microkit/shared/rabbitmq"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type record struct {
+	ID            int64     `db:"id"`
+	AggregateID   string    `db:"aggregate_id"`
+	Exchange      string    `db:"exchange"`
+	RoutingKey    string    `db:"routing_key"`
+	MessageID     string    `db:"message_id"`
+	EventType     string    `db:"event_type"`
+	Source        string    `db:"source"`
+	ContentType   string    `db:"content_type"`
+	SchemaVersion int       `db:"schema_version"`
+	Payload       []byte    `db:"payload"`
+	CreatedAt     time.Time `db:"created_at"`
+}
+
+func (r record) envelope() rabbitmq.Envelope {
+	return rabbitmq.Envelope{
+		ID:            r.MessageID,
+		Type:          r.EventType,
+		Source:        r.Source,
+		Time:          r.CreatedAt,
+		SchemaVersion: r.SchemaVersion,
+		ContentType:   r.ContentType,
+		Data:          r.Payload,
+	}
+}
+
+// Add stores event in the outbox as part of tx. Events sharing an aggregateID
+// are published in the order they were added.
+func Add(ctx context.Context, tx *sqlx.Tx, bus *rabbitmq.EventBus, aggregateID string, event rabbitmq.Event) error {
+	env, err := bus.Envelope(event)
+	if err != nil {
+		return err
+	}
+	return AddEnvelope(ctx, tx, aggregateID, bus.Exchange(), env)
+}
+
+// AddEnvelope stores an already built envelope in the outbox as part of tx.
+func AddEnvelope(ctx context.Context, tx *sqlx.Tx, aggregateID, exchange string, env rabbitmq.Envelope) error {
+	_, err := tx.NamedExecContext(ctx, `
+		INSERT INTO outbox (aggregate_id, exchange, routing_key, message_id, event_type, source, content_type, schema_version, payload, created_at)
+		VALUES (:aggregate_id, :exchange, :routing_key, :message_id, :event_type, :source, :content_type, :schema_version, :payload, :created_at)`,
+		record{
+			AggregateID:   aggregateID,
+			Exchange:      exchange,
+			RoutingKey:    rabbitmq.RoutingKey(env.Type),
+			MessageID:     env.ID,
+			EventType:     env.Type,
+			Source:        env.Source,
+			ContentType:   env.ContentType,
+			SchemaVersion: env.SchemaVersion,
+			Payload:       env.Data,
+			CreatedAt:     env.Time,
+		})
+	return err
+}
+
+// Relay polls the outbox and publishes pending rows with publisher confirms.
+// Several replicas may run a Relay against the same table.
+type Relay struct {
+	db     database.DB
+	mq     rabbitmq.Broker
+	logger *zap.Logger
+
+	// Interval between polls when the outbox is empty.
+	Interval time.Duration
+	// BatchSize is the maximum number of rows claimed per transaction.
+	BatchSize int
+	// Retain marks published rows instead of deleting them.
+	Retain bool
+}
+
+func NewRelay(db database.DB, mq rabbitmq.Broker, logger *zap.Logger) *Relay {
+	return &Relay{
+		db:        db,
+		mq:        mq,
+		logger:    logger,
+		Interval:  time.Second,
+		BatchSize: 100,
+	}
+}
+
+// Run publishes outbox rows until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) {
+	r.logger.Info("Starting outbox relay")
+	for {
+		n, err := r.PublishPending(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.logger.Error("Failed to relay outbox", zap.Error(err))
+		}
+
+		// Keep draining while there is work, otherwise wait for the next poll.
+		wait := r.Interval
+		if err == nil && n > 0 {
+			wait = 0
+		}
+		select {
+		case <-ctx.Done():
+			r.logger.Info("Outbox relay stopped")
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// PublishPending claims one batch of rows, publishes them and returns how many
+// were published.
+//
+// Only the oldest pending row of each aggregate is claimed, so a later event can
+// never overtake an earlier one that another replica holds locked. Rows that
+// fail to publish stay in the outbox and are retried on the next poll.
+func (r *Relay) PublishPending(ctx context.Context) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var rows []record
+	err = tx.SelectContext(ctx, &rows, `
+		SELECT id, aggregate_id, exchange, routing_key, message_id, event_type, source, content_type, schema_version, payload, created_at
+		FROM outbox
+		WHERE id IN (
+			SELECT min(id) FROM outbox WHERE published_at IS NULL GROUP BY aggregate_id
+		)
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED`, r.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, row := range rows {
+		if err := r.mq.PublishConfirmed(ctx, row.Exchange, row.RoutingKey, row.envelope().Publishing()); err != nil {
+			r.logger.Warn("Failed to publish outbox row", zap.Int64("id", row.ID), zap.String("aggregate_id", row.AggregateID), zap.Error(err))
+			continue
+		}
+		if err := r.markPublished(ctx, tx, row.ID); err != nil {
+			return 0, err
+		}
+		published++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return published, nil
+}
+
+func (r *Relay) markPublished(ctx context.Context, tx *sqlx.Tx, id int64) error {
+	query := `DELETE FROM outbox WHERE id = $1`
+	if r.Retain {
+		query = `UPDATE outbox SET published_at = now() WHERE id = $1`
+	}
+	_, err := tx.ExecContext(ctx, query, id)
+	return err
+}
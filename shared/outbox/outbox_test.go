@@ -0,0 +1,195 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/MuxSphere/microkit/shared/database/databasetest"
+	"github.com/MuxSphere/microkit/shared/rabbitmq"
+	"github.com/MuxSphere/microkit/shared/rabbitmq/mocks"
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type itemCreated struct {
+	ID string `json:"id"`
+}
+
+func (itemCreated) EventType() string { return "item.created" }
+
+var outboxColumns = []string{"id", "aggregate_id", "exchange", "routing_key", "message_id", "event_type", "source", "content_type", "schema_version", "payload", "created_at"}
+
+const pendingQuery = `SELECT .* FROM outbox WHERE id IN \( SELECT min\(id\) FROM outbox WHERE published_at IS NULL GROUP BY aggregate_id \) ORDER BY id LIMIT \$1 FOR UPDATE SKIP LOCKED`
+
+// pendingRows returns outbox rows with the given IDs and message IDs. The
+// first letter of a message ID is its aggregate ID.
+func pendingRows(ids []int, messageIDs ...string) *sqlmock.Rows {
+	out := sqlmock.NewRows(outboxColumns)
+	for i, id := range ids {
+		out.AddRow(id, messageIDs[i][:1], "events", "item.created", messageIDs[i], "item.created", "service-a", rabbitmq.ContentTypeJSON, 1, []byte(`{}`), time.Now())
+	}
+	return out
+}
+
+// recordPublishes makes mq accept confirmed publishes, or fail those whose
+// message ID is in failing, and returns the IDs published so far.
+func recordPublishes(mq *mocks.Broker, failing ...string) *[]string {
+	var published []string
+	mq.EXPECT().PublishConfirmed(mock.Anything, "events", "item.created", mock.Anything).
+		RunAndReturn(func(ctx context.Context, exchange, key string, msg amqp.Publishing) error {
+			for _, id := range failing {
+				if msg.MessageId == id {
+					return errors.New("nacked")
+				}
+			}
+			published = append(published, msg.MessageId)
+			return nil
+		})
+	return &published
+}
+
+func TestAdd(t *testing.T) {
+	db, sqlMock, err := databasetest.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mq := mocks.NewBroker(t)
+	mq.EXPECT().DeclareExchange("events", amqp.ExchangeTopic).Return(nil)
+	bus, err := rabbitmq.NewEventBus(mq, "events", "service-a", rabbitmq.ContentTypeJSON, zap.NewNop())
+	require.NoError(t, err)
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(`INSERT INTO outbox`).
+		WithArgs("item-1", "events", "item.created", sqlmock.AnyArg(), "item.created", "service-a", rabbitmq.ContentTypeJSON, 1, []byte(`{"id":"item-1"}`), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	sqlMock.ExpectCommit()
+
+	tx, err := db.Beginx()
+	require.NoError(t, err)
+	assert.ErrorIs(t, Add(context.Background(), tx, bus, "item-1", itemCreated{ID: "item-1"}), rabbitmq.ErrUnknownEventType)
+	bus.Register(itemCreated{}, 1)
+	require.NoError(t, Add(context.Background(), tx, bus, "item-1", itemCreated{ID: "item-1"}))
+	require.NoError(t, tx.Commit())
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestRelayPublishesInAggregateOrder(t *testing.T) {
+	db, sqlMock, err := databasetest.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mq := mocks.NewBroker(t)
+	published := recordPublishes(mq)
+	relay := NewRelay(db, mq, zap.NewNop())
+
+	// Rows 1 and 2 belong to aggregate a, so only 1 is claimed in the first batch
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery(pendingQuery).WithArgs(100).WillReturnRows(pendingRows([]int{1, 3}, "a1", "b1"))
+	sqlMock.ExpectExec(`DELETE FROM outbox WHERE id = \$1`).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectExec(`DELETE FROM outbox WHERE id = \$1`).WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery(pendingQuery).WithArgs(100).WillReturnRows(pendingRows([]int{2}, "a2"))
+	sqlMock.ExpectExec(`DELETE FROM outbox WHERE id = \$1`).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+
+	n, err := relay.PublishPending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	n, err = relay.PublishPending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	assert.Equal(t, []string{"a1", "b1", "a2"}, *published)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestRelayKeepsFailedRowsPending(t *testing.T) {
+	db, sqlMock, err := databasetest.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mq := mocks.NewBroker(t)
+	published := recordPublishes(mq, "a1")
+	relay := NewRelay(db, mq, zap.NewNop())
+
+	// The failed row is neither deleted nor marked, so the next poll claims it again
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery(pendingQuery).WillReturnRows(pendingRows([]int{1, 2}, "a1", "b1"))
+	sqlMock.ExpectExec(`DELETE FROM outbox WHERE id = \$1`).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+
+	n, err := relay.PublishPending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []string{"b1"}, *published)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestRelayRetainsPublishedRows(t *testing.T) {
+	db, sqlMock, err := databasetest.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mq := mocks.NewBroker(t)
+	published := recordPublishes(mq)
+	relay := NewRelay(db, mq, zap.NewNop())
+	relay.Retain = true
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery(pendingQuery).WillReturnRows(pendingRows([]int{1}, "a1"))
+	sqlMock.ExpectExec(`UPDATE outbox SET published_at = now\(\) WHERE id = \$1`).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+
+	n, err := relay.PublishPending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []string{"a1"}, *published)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestRelayWithMemoryBroker(t *testing.T) {
+	db, sqlMock, err := databasetest.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mq := rabbitmq.NewMemoryBroker(zap.NewNop())
+	defer mq.Close()
+	bus, err := rabbitmq.NewEventBus(mq, "events", "service-a", rabbitmq.ContentTypeJSON, zap.NewNop())
+	require.NoError(t, err)
+	bus.Register(itemCreated{}, 1)
+
+	received := make(chan itemCreated, 2)
+	require.NoError(t, rabbitmq.Subscribe(bus, "service-b.items", func(ctx context.Context, env rabbitmq.Envelope, e itemCreated) error {
+		received <- e
+		return nil
+	}))
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery(pendingQuery).WillReturnRows(sqlmock.NewRows(outboxColumns).
+		AddRow(1, "item-1", "events", "item.created", "m1", "item.created", "service-a", rabbitmq.ContentTypeJSON, 1, []byte(`{"id":"item-1"}`), time.Now()).
+		AddRow(2, "item-2", "events", "item.created", "m2", "item.created", "service-a", rabbitmq.ContentTypeJSON, 1, []byte(`{"id":"item-2"}`), time.Now()))
+	sqlMock.ExpectExec(`DELETE FROM outbox WHERE id = \$1`).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectExec(`DELETE FROM outbox WHERE id = \$1`).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+
+	n, err := NewRelay(db, mq, zap.NewNop()).PublishPending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+
+	for _, want := range []string{"item-1", "item-2"} {
+		select {
+		case e := <-received:
+			assert.Equal(t, want, e.ID)
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for event")
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package rabbitmq
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+const (
+	// Headers exchange that routes delayed messages into their delay queue.
+	delayExchange = "microkit.delay"
+
+	// Headers exchanges ignore x- prefixed headers when matching, so the
+	// routing headers must not use that prefix.
+	headerDelayExchange = "microkit-delay-exchange"
+	headerDelayMillis   = "microkit-delay-ms"
+
+	// Delay queues are removed by the broker once they have not been declared
+	// for this long plus their delay. Publishing does not count as use, so
+	// queues still in use are declared again after delayQueueRedeclare.
+	delayQueueExpiry    = 24 * time.Hour
+	delayQueueRedeclare = delayQueueExpiry / 2
+)
+
+// delayQueues remembers when delay queues were declared on this connection.
+type delayQueues struct {
+	mu       sync.Mutex
+	exchange bool
+	declared map[string]time.Time
+	now      func() time.Time
+}
+
+// PublishAfter publishes msg to exchange once delay has elapsed.
+//
+// The message waits in a queue whose TTL equals the delay and is dead-lettered
+// into exchange when it expires, keeping its original routing key. There is one
+// such queue per target exchange and delay, so no broker plugin is needed.
+// To bound the number of queues, delays are rounded up by less than 1/32 of
+// the delay, e.g. 10.2s becomes 10.24s. Delays under 64ms are kept to the
+// millisecond.
+func (r *RabbitMQ) PublishAfter(ctx context.Context, exchange, routingKey string, msg amqp.Publishing, delay time.Duration) error {
+	return publishAfter(ctx, r, &r.delay, exchange, routingKey, msg, delay)
+}
+
+// PublishAt publishes msg to exchange at the given time, rounded like the
+// delay of PublishAfter. Use shared/scheduler when the time must be exact or
+// the message may need to be cancelled.
+func (r *RabbitMQ) PublishAt(ctx context.Context, exchange, routingKey string, msg amqp.Publishing, at time.Time) error {
+	return r.PublishAfter(ctx, exchange, routingKey, msg, time.Until(at))
+}
+
+func publishAfter(ctx context.Context, b Broker, queues *delayQueues, exchange, routingKey string, msg amqp.Publishing, delay time.Duration) error {
+	ms := delayBucket(delay.Milliseconds())
+	if ms <= 0 {
+		return b.Publish(ctx, exchange, routingKey, msg)
+	}
+
+	if err := queues.declare(b, exchange, ms); err != nil {
+		return err
+	}
+
+	msg.Headers = delayHeaders(msg.Headers, exchange, ms)
+	return b.Publish(ctx, delayExchange, routingKey, msg)
+}
+
+func (q *delayQueues) declare(b Broker, exchange string, ms int64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.exchange {
+		if err := b.DeclareExchange(delayExchange, amqp.ExchangeHeaders); err != nil {
+			return err
+		}
+		q.exchange = true
+		q.declared = make(map[string]time.Time)
+	}
+
+	now := time.Now
+	if q.now != nil {
+		now = q.now
+	}
+	queue := fmt.Sprintf("%s.%s.%d", delayExchange, exchange, ms)
+	if at, ok := q.declared[queue]; ok && now().Sub(at) < delayQueueRedeclare {
+		return nil
+	}
+
+	err := b.DeclareQueue(queue, amqp.Table{
+		"x-message-ttl":          ms,
+		"x-dead-letter-exchange": exchange,
+		"x-expires":              (delayQueueExpiry + time.Duration(ms)*time.Millisecond).Milliseconds(),
+	})
+	if err != nil {
+		return err
+	}
+
+	err = b.BindQueue(queue, "", delayExchange, delayBinding(exchange, ms))
+	if err != nil {
+		return err
+	}
+
+	q.declared[queue] = now()
+	return nil
+}
+
+// delayBucket rounds ms up to a multiple of the largest power of two that is
+// at most 1/32 of it.
+func delayBucket(ms int64) int64 {
+	step := int64(1)
+	for step*64 <= ms {
+		step *= 2
+	}
+	return (ms + step - 1) / step * step
+}
+
+// delayHeaders returns a copy of headers with the headers routing a message
+// into the delay queue for exchange and ms.
+func delayHeaders(headers amqp.Table, exchange string, ms int64) amqp.Table {
+	out := amqp.Table{}
+	for k, v := range headers {
+		out[k] = v
+	}
+	out[headerDelayExchange] = exchange
+	out[headerDelayMillis] = ms
+	return out
+}
+
+// delayBinding returns the arguments binding the delay queue for exchange and
+// ms to the delay exchange.
+func delayBinding(exchange string, ms int64) amqp.Table {
+	return amqp.Table{
+		"x-match":           "all",
+		headerDelayExchange: exchange,
+		headerDelayMillis:   ms,
+	}
+}
@@ -0,0 +1,114 @@
+package rabbitmq
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestDelayRoutesOnlyToTarget(t *testing.T) {
+	b := NewMemoryBroker(zap.NewNop())
+	defer b.Close()
+
+	for _, name := range []string{"events", "audit"} {
+		require.NoError(t, b.DeclareExchange(name, amqp.ExchangeFanout))
+		require.NoError(t, b.DeclareQueue(name, nil))
+		require.NoError(t, b.BindQueue(name, "", name, nil))
+	}
+	events, err := b.Consume("events", "test")
+	require.NoError(t, err)
+	audit, err := b.Consume("audit", "test")
+	require.NoError(t, err)
+
+	// Both targets have a delay queue for the same delay
+	ctx := context.Background()
+	require.NoError(t, b.PublishAfter(ctx, "events", "item.created", amqp.Publishing{Body: []byte("event")}, 20*time.Millisecond))
+	require.NoError(t, b.PublishAfter(ctx, "audit", "item.created", amqp.Publishing{Body: []byte("audit")}, 20*time.Millisecond))
+
+	assert.Equal(t, "event", string(receive(t, events).Body))
+	assert.Equal(t, "audit", string(receive(t, audit).Body))
+	select {
+	case d := <-events:
+		t.Fatalf("unexpected delivery %q on events", d.Body)
+	case d := <-audit:
+		t.Fatalf("unexpected delivery %q on audit", d.Body)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// declaringBroker records the queues declared and bound through it.
+type declaringBroker struct {
+	*MemoryBroker
+	queues   []amqp.Table
+	bindings []amqp.Table
+}
+
+func (b *declaringBroker) DeclareQueue(name string, args amqp.Table) error {
+	b.queues = append(b.queues, args)
+	return b.MemoryBroker.DeclareQueue(name, args)
+}
+
+func (b *declaringBroker) BindQueue(queue, routingKey, exchange string, args amqp.Table) error {
+	b.bindings = append(b.bindings, args)
+	return b.MemoryBroker.BindQueue(queue, routingKey, exchange, args)
+}
+
+func TestDelayQueueDeclaration(t *testing.T) {
+	b := &declaringBroker{MemoryBroker: NewMemoryBroker(zap.NewNop())}
+	defer b.Close()
+	require.NoError(t, b.DeclareExchange("events", amqp.ExchangeTopic))
+
+	now := time.Now()
+	queues := &delayQueues{now: func() time.Time { return now }}
+	publish := func(delay time.Duration) {
+		t.Helper()
+		require.NoError(t, publishAfter(context.Background(), b, queues, "events", "reminder.due", amqp.Publishing{}, delay))
+	}
+
+	publish(10200 * time.Millisecond)
+	require.Len(t, b.queues, 1)
+	assert.Equal(t, amqp.Table{
+		"x-message-ttl":          int64(10240),
+		"x-dead-letter-exchange": "events",
+		"x-expires":              (24*time.Hour + 10240*time.Millisecond).Milliseconds(),
+	}, b.queues[0])
+	assert.Equal(t, amqp.Table{
+		"x-match":                 "all",
+		"microkit-delay-exchange": "events",
+		"microkit-delay-ms":       int64(10240),
+	}, b.bindings[0])
+
+	// Nearby delays share the queue, and it is not declared again while fresh
+	publish(10210 * time.Millisecond)
+	now = now.Add(delayQueueRedeclare - time.Minute)
+	publish(10 * time.Second)
+	assert.Len(t, b.queues, 1)
+
+	// Before the broker expires it, the queue and its binding are declared again
+	now = now.Add(time.Minute)
+	publish(10 * time.Second)
+	assert.Len(t, b.queues, 2)
+	assert.Len(t, b.bindings, 2)
+	assert.Equal(t, b.queues[0], b.queues[1])
+}
+
+func TestDelayBucket(t *testing.T) {
+	for ms, want := range map[int64]int64{
+		1:        1,
+		63:       63,
+		64:       64,
+		65:       66,
+		10200:    10240,
+		3600000:  3604480,
+		86400001: 88080384,
+	} {
+		got := delayBucket(ms)
+		assert.Equal(t, want, got, ms)
+		assert.Less(t, got-ms, ms/32+1, ms)
+	}
+}
@@ -0,0 +1,271 @@
+package rabbitmq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	apperrors "github.com/MuxSphere/microkit/shared/errors"
+	"github.com/google/uuid"
+	"github.com/streadway/amqp"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/protobuf"
+
+	specVersion = "1.0"
+
+	// Envelope attributes travel as AMQP headers using the CloudEvents AMQP
+	// binding prefix.
+	headerSpecVersion   = "cloudEvents:specversion"
+	headerSchemaVersion = "cloudEvents:schemaversion"
+)
+
+var (
+	ErrUnknownEventType       = errors.New("unknown event type")
+	ErrUnsupportedContentType = errors.New("unsupported content type")
+)
+
+// Event is implemented by every payload sent through an EventBus. The event
+// type also determines the routing key.
+type Event interface {
+	EventType() string
+}
+
+// Envelope holds the CloudEvents-style metadata of a published event.
+type Envelope struct {
+	ID            string
+	Type          string
+	Source        string
+	Time          time.Time
+	SchemaVersion int
+	ContentType   string
+	Data          []byte
+}
+
+// RoutingKey returns the routing key events of the given type are published with.
+func RoutingKey(eventType string) string {
+	return eventType
+}
+
+// EventBus publishes and consumes typed events on a topic exchange.
+type EventBus struct {
+	mq          Broker
+	exchange    string
+	source      string
+	contentType string
+	logger      *zap.Logger
+
+	mu       sync.RWMutex
+	versions map[string]int
+}
+
+func NewEventBus(mq Broker, exchange, source, contentType string, logger *zap.Logger) (*EventBus, error) {
+	if contentType != ContentTypeJSON && contentType != ContentTypeProtobuf {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedContentType, contentType)
+	}
+	if err := mq.DeclareExchange(exchange, amqp.ExchangeTopic); err != nil {
+		return nil, err
+	}
+
+	return &EventBus{
+		mq:          mq,
+		exchange:    exchange,
+		source:      source,
+		contentType: contentType,
+		logger:      logger,
+		versions:    make(map[string]int),
+	}, nil
+}
+
+func (b *EventBus) Exchange() string {
+	return b.exchange
+}
+
+// Register makes an event type known to the bus. Publishing or subscribing to
+// an unregistered type fails with ErrUnknownEventType.
+func (b *EventBus) Register(event Event, schemaVersion int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.versions[event.EventType()] = schemaVersion
+}
+
+func (b *EventBus) schemaVersion(eventType string) (int, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	v, ok := b.versions[eventType]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownEventType, eventType)
+	}
+	return v, nil
+}
+
+// Publish wraps event in an envelope, encodes it with the bus content type and
+// publishes it with a routing key derived from its type.
+func (b *EventBus) Publish(ctx context.Context, event Event) error {
+	env, err := b.Envelope(event)
+	if err != nil {
+		return err
+	}
+	if err := b.mq.Publish(ctx, b.exchange, RoutingKey(env.Type), env.Publishing()); err != nil {
+		return err
+	}
+
+	b.logger.Debug("Event published", zap.String("type", env.Type), zap.String("id", env.ID))
+	return nil
+}
+
+// Envelope builds the envelope Publish would send for event without sending it.
+func (b *EventBus) Envelope(event Event) (Envelope, error) {
+	version, err := b.schemaVersion(event.EventType())
+	if err != nil {
+		return Envelope{}, err
+	}
+	data, err := encode(b.contentType, event)
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	return Envelope{
+		ID:            uuid.NewString(),
+		Type:          event.EventType(),
+		Source:        b.source,
+		Time:          time.Now().UTC(),
+		SchemaVersion: version,
+		ContentType:   b.contentType,
+		Data:          data,
+	}, nil
+}
+
+// Subscribe consumes events of type T from a durable queue called name bound to
+// the bus exchange. Deliveries are acknowledged when handler succeeds and
+// otherwise requeued or dead-lettered as decided by apperrors.Decide.
+func Subscribe[T Event](b *EventBus, name string, handler func(ctx context.Context, env Envelope, event T) error) error {
+	eventType := newEvent[T]().EventType()
+	if _, err := b.schemaVersion(eventType); err != nil {
+		return err
+	}
+
+	if err := b.mq.DeclareQueue(name, nil); err != nil {
+		return err
+	}
+	if err := b.mq.BindQueue(name, RoutingKey(eventType), b.exchange, nil); err != nil {
+		return err
+	}
+	msgs, err := b.mq.Consume(name, "")
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for d := range msgs {
+			env := EnvelopeFromDelivery(d)
+			if env.Type != eventType {
+				b.logger.Warn("Rejecting event of unexpected type", zap.String("queue", name), zap.String("type", env.Type))
+				d.Reject(false)
+				continue
+			}
+
+			event := newEvent[T]()
+			if err := decode(env.ContentType, env.Data, &event); err != nil {
+				b.logger.Error("Failed to decode event", zap.String("queue", name), zap.String("id", env.ID), zap.Error(err))
+				d.Reject(false)
+				continue
+			}
+
+			err := handler(context.Background(), env, event)
+			decision, _ := apperrors.Settle(d, err)
+			if err != nil {
+				b.logger.Error("Error handling event", zap.String("queue", name), zap.String("id", env.ID), zap.Stringer("decision", decision), zap.Error(err))
+			}
+		}
+	}()
+
+	b.logger.Info("Subscribed to events", zap.String("queue", name), zap.String("type", eventType))
+	return nil
+}
+
+// Publishing converts the envelope to an AMQP message.
+func (e Envelope) Publishing() amqp.Publishing {
+	return amqp.Publishing{
+		MessageId:    e.ID,
+		Type:         e.Type,
+		AppId:        e.Source,
+		Timestamp:    e.Time,
+		ContentType:  e.ContentType,
+		DeliveryMode: amqp.Persistent,
+		Headers: amqp.Table{
+			headerSpecVersion:   specVersion,
+			headerSchemaVersion: int32(e.SchemaVersion),
+		},
+		Body: e.Data,
+	}
+}
+
+// EnvelopeFromDelivery reads the envelope attributes back from an AMQP delivery.
+func EnvelopeFromDelivery(d amqp.Delivery) Envelope {
+	env := Envelope{
+		ID:          d.MessageId,
+		Type:        d.Type,
+		Source:      d.AppId,
+		Time:        d.Timestamp,
+		ContentType: d.ContentType,
+		Data:        d.Body,
+	}
+	switch v := d.Headers[headerSchemaVersion].(type) {
+	case int32:
+		env.SchemaVersion = int(v)
+	case int64:
+		env.SchemaVersion = int(v)
+	case int:
+		env.SchemaVersion = v
+	}
+	return env
+}
+
+func encode(contentType string, event Event) ([]byte, error) {
+	switch contentType {
+	case ContentTypeJSON:
+		return json.Marshal(event)
+	case ContentTypeProtobuf:
+		msg, ok := event.(proto.Message)
+		if !ok {
+			return nil, fmt.Errorf("event %s is not a protobuf message", event.EventType())
+		}
+		return proto.Marshal(msg)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedContentType, contentType)
+	}
+}
+
+func decode[T Event](contentType string, data []byte, event *T) error {
+	switch contentType {
+	case ContentTypeJSON:
+		return json.Unmarshal(data, event)
+	case ContentTypeProtobuf:
+		msg, ok := any(*event).(proto.Message)
+		if !ok {
+			return fmt.Errorf("event %s is not a protobuf message", (*event).EventType())
+		}
+		return proto.Unmarshal(data, msg)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedContentType, contentType)
+	}
+}
+
+// newEvent returns a usable zero value of T, allocating the pointee when T is
+// a pointer type such as a generated protobuf message.
+func newEvent[T Event]() T {
+	var event T
+	if t := reflect.TypeOf((*T)(nil)).Elem(); t.Kind() == reflect.Pointer {
+		event = reflect.New(t.Elem()).Interface().(T)
+	}
+	return event
+}
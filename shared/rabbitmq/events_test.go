@@ -0,0 +1,72 @@
+package rabbitmq
+
+import (
+	"testing"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type orderPlaced struct {
+	OrderID string `json:"order_id"`
+	Amount  int    `json:"amount"`
+}
+
+func (orderPlaced) EventType() string { return "order.placed" }
+
+type orderShipped struct{}
+
+func (*orderShipped) EventType() string { return "order.shipped" }
+
+func newTestBus() *EventBus {
+	return &EventBus{
+		exchange:    "events",
+		source:      "service-a",
+		contentType: ContentTypeJSON,
+		logger:      zap.NewNop(),
+		versions:    make(map[string]int),
+	}
+}
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	bus := newTestBus()
+	bus.Register(orderPlaced{}, 2)
+
+	env, err := bus.Envelope(orderPlaced{OrderID: "42", Amount: 7})
+	require.NoError(t, err)
+	assert.Equal(t, "order.placed", env.Type)
+	assert.Equal(t, "service-a", env.Source)
+	assert.Equal(t, 2, env.SchemaVersion)
+	assert.NotEmpty(t, env.ID)
+
+	msg := env.Publishing()
+	got := EnvelopeFromDelivery(amqp.Delivery{
+		MessageId:   msg.MessageId,
+		Type:        msg.Type,
+		AppId:       msg.AppId,
+		Timestamp:   msg.Timestamp,
+		ContentType: msg.ContentType,
+		Headers:     msg.Headers,
+		Body:        msg.Body,
+	})
+	assert.Equal(t, env, got)
+
+	event := newEvent[orderPlaced]()
+	require.NoError(t, decode(got.ContentType, got.Data, &event))
+	assert.Equal(t, orderPlaced{OrderID: "42", Amount: 7}, event)
+}
+
+func TestUnknownEventTypeRejected(t *testing.T) {
+	bus := newTestBus()
+
+	_, err := bus.Envelope(orderPlaced{})
+	assert.ErrorIs(t, err, ErrUnknownEventType)
+}
+
+func TestNewEventAllocatesPointers(t *testing.T) {
+	event := newEvent[*orderShipped]()
+	assert.NotNil(t, event)
+	assert.Equal(t, "order.shipped", event.EventType())
+}
@@ -0,0 +1,575 @@
+package rabbitmq
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/streadway/amqp"
+	"go.uber.org/zap"
+)
+
+// MemoryBroker is an in-process Broker for tests. It routes through direct,
+// topic, fanout and headers exchanges as well as the default exchange, tracks
+// acknowledgements, redelivers requeued messages and dead-letters rejected or
+// expired ones according to the queue arguments. Nothing is persisted.
+type MemoryBroker struct {
+	logger *zap.Logger
+
+	mu        sync.Mutex
+	exchanges map[string]*memExchange
+	queues    map[string]*memQueue
+	unacked   map[uint64]*memPending
+	nextTag   uint64
+	closed    bool
+	done      chan struct{}
+
+	delay delayQueues
+}
+
+var _ Broker = (*MemoryBroker)(nil)
+
+type memExchange struct {
+	kind     string
+	bindings []memBinding
+}
+
+type memBinding struct {
+	queue string
+	key   string
+	args  amqp.Table
+}
+
+type memQueue struct {
+	name    string
+	args    amqp.Table
+	ready   []*memMessage
+	cond    *sync.Cond
+	deleted bool
+}
+
+type memMessage struct {
+	exchange    string
+	routingKey  string
+	msg         amqp.Publishing
+	redelivered bool
+	timer       *time.Timer
+}
+
+type memPending struct {
+	queue   *memQueue
+	message *memMessage
+}
+
+func NewMemoryBroker(logger *zap.Logger) *MemoryBroker {
+	return &MemoryBroker{
+		logger:    logger,
+		exchanges: make(map[string]*memExchange),
+		queues:    make(map[string]*memQueue),
+		unacked:   make(map[uint64]*memPending),
+		done:      make(chan struct{}),
+	}
+}
+
+func (b *MemoryBroker) PublishMessage(exchange, routingKey string, body []byte) error {
+	return b.Publish(context.Background(), exchange, routingKey, amqp.Publishing{
+		ContentType: "text/plain",
+		Body:        body,
+	})
+}
+
+func (b *MemoryBroker) ConsumeMessages(queue string, handler func([]byte) error) error {
+	msgs, err := b.Consume(queue, "")
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for d := range msgs {
+			d.Ack(false)
+			if err := handler(d.Body); err != nil {
+				b.logger.Error("Error processing message", zap.Error(err))
+			}
+		}
+	}()
+	return nil
+}
+
+func (b *MemoryBroker) Publish(ctx context.Context, exchange, routingKey string, msg amqp.Publishing) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return amqp.ErrClosed
+	}
+	return b.route(exchange, routingKey, msg)
+}
+
+// PublishConfirmed behaves like Publish; the in-memory broker confirms every
+// message it accepts.
+func (b *MemoryBroker) PublishConfirmed(ctx context.Context, exchange, routingKey string, msg amqp.Publishing) error {
+	return b.Publish(ctx, exchange, routingKey, msg)
+}
+
+func (b *MemoryBroker) PublishAfter(ctx context.Context, exchange, routingKey string, msg amqp.Publishing, delay time.Duration) error {
+	return publishAfter(ctx, b, &b.delay, exchange, routingKey, msg, delay)
+}
+
+func (b *MemoryBroker) PublishAt(ctx context.Context, exchange, routingKey string, msg amqp.Publishing, at time.Time) error {
+	return b.PublishAfter(ctx, exchange, routingKey, msg, time.Until(at))
+}
+
+func (b *MemoryBroker) Consume(queue, consumer string) (<-chan amqp.Delivery, error) {
+	b.mu.Lock()
+	q, ok := b.queues[queue]
+	b.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("queue %q not found", queue)
+	}
+
+	out := make(chan amqp.Delivery)
+	go func() {
+		defer close(out)
+		for {
+			d, ok := b.next(q, consumer)
+			if !ok {
+				return
+			}
+			select {
+			case out <- d:
+			case <-b.done:
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// Call sends req with a temporary reply queue standing in for direct reply-to.
+func (b *MemoryBroker) Call(ctx context.Context, exchange, routingKey string, req []byte) ([]byte, error) {
+	ctx, cancel := withCallTimeout(ctx)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+
+	replyQueue := "amq.gen-" + uuid.NewString()
+	if err := b.DeclareQueue(replyQueue, nil); err != nil {
+		return nil, err
+	}
+	defer b.deleteQueue(replyQueue)
+	replies, err := b.Consume(replyQueue, "")
+	if err != nil {
+		return nil, err
+	}
+
+	correlationID := uuid.NewString()
+	err = b.Publish(ctx, exchange, routingKey, amqp.Publishing{
+		ContentType:   ContentTypeJSON,
+		CorrelationId: correlationID,
+		ReplyTo:       replyQueue,
+		Expiration:    expiration(deadline),
+		Body:          req,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		select {
+		case d, ok := <-replies:
+			if !ok {
+				return nil, ErrRPCChannelClosed
+			}
+			d.Ack(false)
+			if d.CorrelationId == correlationID {
+				return replyResult(d)
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (b *MemoryBroker) ServeRPC(queue string, handler func(ctx context.Context, req []byte) ([]byte, error)) error {
+	msgs, err := b.Consume(queue, "")
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for d := range msgs {
+			serveCall(b, b.logger, d, handler)
+		}
+	}()
+	return nil
+}
+
+func (b *MemoryBroker) DeclareExchange(name, kind string) error {
+	switch kind {
+	case amqp.ExchangeDirect, amqp.ExchangeTopic, amqp.ExchangeFanout, amqp.ExchangeHeaders:
+	default:
+		return fmt.Errorf("unsupported exchange kind %q", kind)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if ex, ok := b.exchanges[name]; ok {
+		if ex.kind != kind {
+			return fmt.Errorf("exchange %q already declared as %s", name, ex.kind)
+		}
+		return nil
+	}
+	b.exchanges[name] = &memExchange{kind: kind}
+	return nil
+}
+
+func (b *MemoryBroker) DeclareQueue(name string, args amqp.Table) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.queues[name]; ok {
+		return nil
+	}
+	b.queues[name] = &memQueue{name: name, args: args, cond: sync.NewCond(&b.mu)}
+	return nil
+}
+
+func (b *MemoryBroker) BindQueue(queue, routingKey, exchange string, args amqp.Table) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ex, ok := b.exchanges[exchange]
+	if !ok {
+		return fmt.Errorf("exchange %q not found", exchange)
+	}
+	if _, ok := b.queues[queue]; !ok {
+		return fmt.Errorf("queue %q not found", queue)
+	}
+	ex.bindings = append(ex.bindings, memBinding{queue: queue, key: routingKey, args: args})
+	return nil
+}
+
+func (b *MemoryBroker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	close(b.done)
+	for _, q := range b.queues {
+		q.cond.Broadcast()
+	}
+}
+
+// QueueLen returns the number of messages waiting in queue, not counting
+// delivered but unacknowledged ones.
+func (b *MemoryBroker) QueueLen(queue string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if q, ok := b.queues[queue]; ok {
+		return len(q.ready)
+	}
+	return 0
+}
+
+// Unacked returns the number of delivered messages awaiting acknowledgement.
+func (b *MemoryBroker) Unacked() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.unacked)
+}
+
+// Ack, Nack and Reject implement amqp.Acknowledger for deliveries handed out
+// by the broker.
+func (b *MemoryBroker) Ack(tag uint64, multiple bool) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, err := b.settle(tag, multiple)
+	return err
+}
+
+func (b *MemoryBroker) Nack(tag uint64, multiple bool, requeue bool) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	settled, err := b.settle(tag, multiple)
+	if err != nil {
+		return err
+	}
+	for _, p := range settled {
+		if requeue && !p.queue.deleted {
+			p.message.redelivered = true
+			p.queue.ready = append([]*memMessage{p.message}, p.queue.ready...)
+			p.queue.cond.Signal()
+			continue
+		}
+		b.deadLetter(p.queue, p.message, "rejected")
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Reject(tag uint64, requeue bool) error {
+	return b.Nack(tag, false, requeue)
+}
+
+// settle removes the given delivery tag, or every tag up to it when multiple
+// is set, from the unacknowledged set.
+func (b *MemoryBroker) settle(tag uint64, multiple bool) ([]*memPending, error) {
+	if !multiple {
+		p, ok := b.unacked[tag]
+		if !ok {
+			return nil, fmt.Errorf("unknown delivery tag %d", tag)
+		}
+		delete(b.unacked, tag)
+		return []*memPending{p}, nil
+	}
+
+	var settled []*memPending
+	for t := uint64(1); t <= tag; t++ {
+		if p, ok := b.unacked[t]; ok {
+			settled = append(settled, p)
+			delete(b.unacked, t)
+		}
+	}
+	return settled, nil
+}
+
+// next blocks until q has a message and hands it out as a delivery.
+func (b *MemoryBroker) next(q *memQueue, consumer string) (amqp.Delivery, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for len(q.ready) == 0 && !b.closed && !q.deleted {
+		q.cond.Wait()
+	}
+	if b.closed || q.deleted {
+		return amqp.Delivery{}, false
+	}
+
+	m := q.ready[0]
+	q.ready = q.ready[1:]
+	if m.timer != nil {
+		m.timer.Stop()
+		m.timer = nil
+	}
+
+	b.nextTag++
+	b.unacked[b.nextTag] = &memPending{queue: q, message: m}
+
+	return amqp.Delivery{
+		Acknowledger:    b,
+		Headers:         m.msg.Headers,
+		ContentType:     m.msg.ContentType,
+		ContentEncoding: m.msg.ContentEncoding,
+		DeliveryMode:    m.msg.DeliveryMode,
+		Priority:        m.msg.Priority,
+		CorrelationId:   m.msg.CorrelationId,
+		ReplyTo:         m.msg.ReplyTo,
+		Expiration:      m.msg.Expiration,
+		MessageId:       m.msg.MessageId,
+		Timestamp:       m.msg.Timestamp,
+		Type:            m.msg.Type,
+		UserId:          m.msg.UserId,
+		AppId:           m.msg.AppId,
+		ConsumerTag:     consumer,
+		DeliveryTag:     b.nextTag,
+		Redelivered:     m.redelivered,
+		Exchange:        m.exchange,
+		RoutingKey:      m.routingKey,
+		Body:            m.msg.Body,
+	}, true
+}
+
+func (b *MemoryBroker) deleteQueue(name string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	q, ok := b.queues[name]
+	if !ok {
+		return
+	}
+	q.deleted = true
+	q.cond.Broadcast()
+	delete(b.queues, name)
+	for _, ex := range b.exchanges {
+		kept := ex.bindings[:0]
+		for _, binding := range ex.bindings {
+			if binding.queue != name {
+				kept = append(kept, binding)
+			}
+		}
+		ex.bindings = kept
+	}
+}
+
+// route delivers msg to every queue bound to exchange that matches. Messages
+// nobody is bound for are dropped, as RabbitMQ does for non-mandatory publishes.
+func (b *MemoryBroker) route(exchange, routingKey string, msg amqp.Publishing) error {
+	if exchange == "" {
+		if q, ok := b.queues[routingKey]; ok {
+			b.enqueue(q, exchange, routingKey, msg)
+		}
+		return nil
+	}
+
+	ex, ok := b.exchanges[exchange]
+	if !ok {
+		return fmt.Errorf("exchange %q not found", exchange)
+	}
+	seen := make(map[string]bool)
+	for _, binding := range ex.bindings {
+		if seen[binding.queue] || !binding.matches(ex.kind, routingKey, msg.Headers) {
+			continue
+		}
+		seen[binding.queue] = true
+		b.enqueue(b.queues[binding.queue], exchange, routingKey, msg)
+	}
+	return nil
+}
+
+func (b *MemoryBroker) enqueue(q *memQueue, exchange, routingKey string, msg amqp.Publishing) {
+	m := &memMessage{exchange: exchange, routingKey: routingKey, msg: msg}
+
+	ttl, ok := tableInt(q.args, "x-message-ttl")
+	if ms, err := strconv.ParseInt(msg.Expiration, 10, 64); err == nil && (!ok || ms < ttl) {
+		ttl, ok = ms, true
+	}
+	if ok {
+		m.timer = time.AfterFunc(time.Duration(ttl)*time.Millisecond, func() {
+			b.expire(q, m)
+		})
+	}
+
+	q.ready = append(q.ready, m)
+	q.cond.Signal()
+}
+
+func (b *MemoryBroker) expire(q *memQueue, m *memMessage) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, ready := range q.ready {
+		if ready == m {
+			q.ready = append(q.ready[:i], q.ready[i+1:]...)
+			b.deadLetter(q, m, "expired")
+			return
+		}
+	}
+}
+
+// deadLetter republishes m to the queue's dead letter exchange, if it has one,
+// recording the reason in the x-death header like RabbitMQ does.
+func (b *MemoryBroker) deadLetter(q *memQueue, m *memMessage, reason string) {
+	dlx, ok := q.args["x-dead-letter-exchange"].(string)
+	if !ok {
+		return
+	}
+	routingKey := m.routingKey
+	if key, ok := q.args["x-dead-letter-routing-key"].(string); ok {
+		routingKey = key
+	}
+
+	msg := m.msg
+	headers := amqp.Table{}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	deaths, _ := headers["x-death"].([]interface{})
+	headers["x-death"] = append([]interface{}{amqp.Table{
+		"queue":        q.name,
+		"reason":       reason,
+		"exchange":     m.exchange,
+		"routing-keys": []interface{}{m.routingKey},
+		"count":        int64(len(deaths) + 1),
+	}}, deaths...)
+	msg.Headers = headers
+	// The original per-message TTL does not apply after dead-lettering.
+	msg.Expiration = ""
+
+	if err := b.route(dlx, routingKey, msg); err != nil {
+		b.logger.Warn("Failed to dead-letter message", zap.String("queue", q.name), zap.Error(err))
+	}
+}
+
+func (binding memBinding) matches(kind, routingKey string, headers amqp.Table) bool {
+	switch kind {
+	case amqp.ExchangeFanout:
+		return true
+	case amqp.ExchangeTopic:
+		return topicMatches(strings.Split(binding.key, "."), strings.Split(routingKey, "."))
+	case amqp.ExchangeHeaders:
+		return headersMatch(binding.args, headers)
+	default:
+		return binding.key == routingKey
+	}
+}
+
+// topicMatches reports whether a routing key matches a binding pattern where
+// "*" stands for exactly one word and "#" for zero or more words.
+func topicMatches(pattern, key []string) bool {
+	if len(pattern) == 0 {
+		return len(key) == 0
+	}
+	switch pattern[0] {
+	case "#":
+		for i := 0; i <= len(key); i++ {
+			if topicMatches(pattern[1:], key[i:]) {
+				return true
+			}
+		}
+		return false
+	case "*":
+		return len(key) > 0 && topicMatches(pattern[1:], key[1:])
+	default:
+		return len(key) > 0 && pattern[0] == key[0] && topicMatches(pattern[1:], key[1:])
+	}
+}
+
+// headersMatch applies x-match all (the default) or any. Arguments starting
+// with x- take no part in matching.
+func headersMatch(args, headers amqp.Table) bool {
+	matchAny := args["x-match"] == "any"
+	for k, want := range args {
+		if strings.HasPrefix(k, "x-") {
+			continue
+		}
+		got, ok := headers[k]
+		matched := ok && valuesEqual(want, got)
+		if matched && matchAny {
+			return true
+		}
+		if !matched && !matchAny {
+			return false
+		}
+	}
+	return !matchAny
+}
+
+func valuesEqual(a, b interface{}) bool {
+	if x, ok := toInt64(a); ok {
+		y, ok := toInt64(b)
+		return ok && x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func tableInt(t amqp.Table, key string) (int64, bool) {
+	return toInt64(t[key])
+}
+
+func toInt64(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int16:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	}
+	return 0, false
+}
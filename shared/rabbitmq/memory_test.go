@@ -0,0 +1,161 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func receive(t *testing.T, msgs <-chan amqp.Delivery) amqp.Delivery {
+	t.Helper()
+	select {
+	case d := <-msgs:
+		return d
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for delivery")
+		return amqp.Delivery{}
+	}
+}
+
+func TestMemoryBrokerTopicRouting(t *testing.T) {
+	b := NewMemoryBroker(zap.NewNop())
+	defer b.Close()
+
+	require.NoError(t, b.DeclareExchange("events", amqp.ExchangeTopic))
+	require.NoError(t, b.DeclareQueue("orders", nil))
+	require.NoError(t, b.DeclareQueue("all", nil))
+	require.NoError(t, b.BindQueue("orders", "order.*", "events", nil))
+	require.NoError(t, b.BindQueue("all", "#", "events", nil))
+
+	require.NoError(t, b.PublishMessage("events", "order.placed", []byte("1")))
+	require.NoError(t, b.PublishMessage("events", "item.stock.low", []byte("2")))
+
+	assert.Equal(t, 1, b.QueueLen("orders"))
+	assert.Equal(t, 2, b.QueueLen("all"))
+	assert.Error(t, b.PublishMessage("missing", "key", nil))
+}
+
+func TestMemoryBrokerFanoutAndDefaultExchange(t *testing.T) {
+	b := NewMemoryBroker(zap.NewNop())
+	defer b.Close()
+
+	require.NoError(t, b.DeclareExchange("broadcast", amqp.ExchangeFanout))
+	require.NoError(t, b.DeclareQueue("a", nil))
+	require.NoError(t, b.DeclareQueue("b", nil))
+	require.NoError(t, b.BindQueue("a", "", "broadcast", nil))
+	require.NoError(t, b.BindQueue("b", "", "broadcast", nil))
+
+	require.NoError(t, b.PublishMessage("broadcast", "ignored", []byte("x")))
+	require.NoError(t, b.PublishMessage("", "a", []byte("y")))
+
+	assert.Equal(t, 2, b.QueueLen("a"))
+	assert.Equal(t, 1, b.QueueLen("b"))
+}
+
+func TestMemoryBrokerAckNackAndDeadLetter(t *testing.T) {
+	b := NewMemoryBroker(zap.NewNop())
+	defer b.Close()
+
+	require.NoError(t, b.DeclareExchange("dlx", amqp.ExchangeFanout))
+	require.NoError(t, b.DeclareQueue("dead", nil))
+	require.NoError(t, b.BindQueue("dead", "", "dlx", nil))
+	require.NoError(t, b.DeclareQueue("work", amqp.Table{"x-dead-letter-exchange": "dlx"}))
+
+	msgs, err := b.Consume("work", "test")
+	require.NoError(t, err)
+	require.NoError(t, b.PublishMessage("", "work", []byte("job")))
+
+	d := receive(t, msgs)
+	assert.False(t, d.Redelivered)
+	assert.Equal(t, 1, b.Unacked())
+	require.NoError(t, d.Nack(false, true))
+
+	d = receive(t, msgs)
+	assert.True(t, d.Redelivered)
+	require.NoError(t, d.Reject(false))
+	assert.Equal(t, 0, b.Unacked())
+	assert.Equal(t, 1, b.QueueLen("dead"))
+
+	dead, err := b.Consume("dead", "test")
+	require.NoError(t, err)
+	d = receive(t, dead)
+	deaths := d.Headers["x-death"].([]interface{})
+	assert.Equal(t, "rejected", deaths[0].(amqp.Table)["reason"])
+	require.NoError(t, d.Ack(false))
+	assert.Error(t, d.Ack(false))
+}
+
+func TestMemoryBrokerPublishAfter(t *testing.T) {
+	b := NewMemoryBroker(zap.NewNop())
+	defer b.Close()
+
+	require.NoError(t, b.DeclareExchange("events", amqp.ExchangeDirect))
+	require.NoError(t, b.DeclareQueue("reminders", nil))
+	require.NoError(t, b.BindQueue("reminders", "reminder.due", "events", nil))
+
+	msgs, err := b.Consume("reminders", "test")
+	require.NoError(t, err)
+
+	start := time.Now()
+	require.NoError(t, b.PublishAfter(context.Background(), "events", "reminder.due", amqp.Publishing{Body: []byte("later")}, 50*time.Millisecond))
+	require.NoError(t, b.PublishAfter(context.Background(), "events", "reminder.due", amqp.Publishing{Body: []byte("much later")}, time.Hour))
+
+	d := receive(t, msgs)
+	assert.Equal(t, "later", string(d.Body))
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	assert.Equal(t, 0, b.QueueLen("reminders"))
+}
+
+func TestMemoryBrokerEventBus(t *testing.T) {
+	b := NewMemoryBroker(zap.NewNop())
+	defer b.Close()
+
+	bus, err := NewEventBus(b, "events", "test", ContentTypeJSON, zap.NewNop())
+	require.NoError(t, err)
+	bus.Register(orderPlaced{}, 1)
+
+	received := make(chan orderPlaced, 1)
+	err = Subscribe(bus, "billing", func(ctx context.Context, env Envelope, e orderPlaced) error {
+		received <- e
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, bus.Publish(context.Background(), orderPlaced{OrderID: "7", Amount: 3}))
+	select {
+	case e := <-received:
+		assert.Equal(t, orderPlaced{OrderID: "7", Amount: 3}, e)
+	case <-time.After(time.Second):
+		t.Fatal("event not received")
+	}
+}
+
+func TestMemoryBrokerRPC(t *testing.T) {
+	b := NewMemoryBroker(zap.NewNop())
+	defer b.Close()
+
+	require.NoError(t, b.DeclareExchange("rpc", amqp.ExchangeDirect))
+	require.NoError(t, b.DeclareQueue("echo", nil))
+	require.NoError(t, b.BindQueue("echo", "echo", "rpc", nil))
+	require.NoError(t, b.ServeRPC("echo", func(ctx context.Context, req []byte) ([]byte, error) {
+		if string(req) == "fail" {
+			return nil, errors.New("boom")
+		}
+		return append([]byte("echo: "), req...), nil
+	}))
+
+	reply, err := b.Call(context.Background(), "rpc", "echo", []byte("hi"))
+	require.NoError(t, err)
+	assert.Equal(t, "echo: hi", string(reply))
+
+	_, err = b.Call(context.Background(), "rpc", "echo", []byte("fail"))
+	var remote *RemoteError
+	require.ErrorAs(t, err, &remote)
+	assert.Equal(t, "boom", remote.Message)
+}
@@ -0,0 +1,675 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	amqp "github.com/streadway/amqp"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// Broker is an autogenerated mock type for the Broker type
+type Broker struct {
+	mock.Mock
+}
+
+type Broker_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *Broker) EXPECT() *Broker_Expecter {
+	return &Broker_Expecter{mock: &_m.Mock}
+}
+
+// BindQueue provides a mock function with given fields: queue, routingKey, exchange, args
+func (_m *Broker) BindQueue(queue string, routingKey string, exchange string, args amqp.Table) error {
+	ret := _m.Called(queue, routingKey, exchange, args)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BindQueue")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string, amqp.Table) error); ok {
+		r0 = rf(queue, routingKey, exchange, args)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Broker_BindQueue_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'BindQueue'
+type Broker_BindQueue_Call struct {
+	*mock.Call
+}
+
+// BindQueue is a helper method to define mock.On call
+//   - queue string
+//   - routingKey string
+//   - exchange string
+//   - args amqp.Table
+func (_e *Broker_Expecter) BindQueue(queue interface{}, routingKey interface{}, exchange interface{}, args interface{}) *Broker_BindQueue_Call {
+	return &Broker_BindQueue_Call{Call: _e.mock.On("BindQueue", queue, routingKey, exchange, args)}
+}
+
+func (_c *Broker_BindQueue_Call) Run(run func(queue string, routingKey string, exchange string, args amqp.Table)) *Broker_BindQueue_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string), args[2].(string), args[3].(amqp.Table))
+	})
+	return _c
+}
+
+func (_c *Broker_BindQueue_Call) Return(_a0 error) *Broker_BindQueue_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Broker_BindQueue_Call) RunAndReturn(run func(string, string, string, amqp.Table) error) *Broker_BindQueue_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Call provides a mock function with given fields: ctx, exchange, routingKey, req
+func (_m *Broker) Call(ctx context.Context, exchange string, routingKey string, req []byte) ([]byte, error) {
+	ret := _m.Called(ctx, exchange, routingKey, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Call")
+	}
+
+	var r0 []byte
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []byte) ([]byte, error)); ok {
+		return rf(ctx, exchange, routingKey, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []byte) []byte); ok {
+		r0 = rf(ctx, exchange, routingKey, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, []byte) error); ok {
+		r1 = rf(ctx, exchange, routingKey, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Broker_Call_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Call'
+type Broker_Call_Call struct {
+	*mock.Call
+}
+
+// Call is a helper method to define mock.On call
+//   - ctx context.Context
+//   - exchange string
+//   - routingKey string
+//   - req []byte
+func (_e *Broker_Expecter) Call(ctx interface{}, exchange interface{}, routingKey interface{}, req interface{}) *Broker_Call_Call {
+	return &Broker_Call_Call{Call: _e.mock.On("Call", ctx, exchange, routingKey, req)}
+}
+
+func (_c *Broker_Call_Call) Run(run func(ctx context.Context, exchange string, routingKey string, req []byte)) *Broker_Call_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].([]byte))
+	})
+	return _c
+}
+
+func (_c *Broker_Call_Call) Return(_a0 []byte, _a1 error) *Broker_Call_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Broker_Call_Call) RunAndReturn(run func(context.Context, string, string, []byte) ([]byte, error)) *Broker_Call_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Close provides a mock function with no fields
+func (_m *Broker) Close() {
+	_m.Called()
+}
+
+// Broker_Close_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Close'
+type Broker_Close_Call struct {
+	*mock.Call
+}
+
+// Close is a helper method to define mock.On call
+func (_e *Broker_Expecter) Close() *Broker_Close_Call {
+	return &Broker_Close_Call{Call: _e.mock.On("Close")}
+}
+
+func (_c *Broker_Close_Call) Run(run func()) *Broker_Close_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Broker_Close_Call) Return() *Broker_Close_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *Broker_Close_Call) RunAndReturn(run func()) *Broker_Close_Call {
+	_c.Run(run)
+	return _c
+}
+
+// Consume provides a mock function with given fields: queue, consumer
+func (_m *Broker) Consume(queue string, consumer string) (<-chan amqp.Delivery, error) {
+	ret := _m.Called(queue, consumer)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Consume")
+	}
+
+	var r0 <-chan amqp.Delivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (<-chan amqp.Delivery, error)); ok {
+		return rf(queue, consumer)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) <-chan amqp.Delivery); ok {
+		r0 = rf(queue, consumer)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan amqp.Delivery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(queue, consumer)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Broker_Consume_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Consume'
+type Broker_Consume_Call struct {
+	*mock.Call
+}
+
+// Consume is a helper method to define mock.On call
+//   - queue string
+//   - consumer string
+func (_e *Broker_Expecter) Consume(queue interface{}, consumer interface{}) *Broker_Consume_Call {
+	return &Broker_Consume_Call{Call: _e.mock.On("Consume", queue, consumer)}
+}
+
+func (_c *Broker_Consume_Call) Run(run func(queue string, consumer string)) *Broker_Consume_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Broker_Consume_Call) Return(_a0 <-chan amqp.Delivery, _a1 error) *Broker_Consume_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Broker_Consume_Call) RunAndReturn(run func(string, string) (<-chan amqp.Delivery, error)) *Broker_Consume_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ConsumeMessages provides a mock function with given fields: queue, handler
+func (_m *Broker) ConsumeMessages(queue string, handler func([]byte) error) error {
+	ret := _m.Called(queue, handler)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConsumeMessages")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, func([]byte) error) error); ok {
+		r0 = rf(queue, handler)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Broker_ConsumeMessages_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ConsumeMessages'
+type Broker_ConsumeMessages_Call struct {
+	*mock.Call
+}
+
+// ConsumeMessages is a helper method to define mock.On call
+//   - queue string
+//   - handler func([]byte) error
+func (_e *Broker_Expecter) ConsumeMessages(queue interface{}, handler interface{}) *Broker_ConsumeMessages_Call {
+	return &Broker_ConsumeMessages_Call{Call: _e.mock.On("ConsumeMessages", queue, handler)}
+}
+
+func (_c *Broker_ConsumeMessages_Call) Run(run func(queue string, handler func([]byte) error)) *Broker_ConsumeMessages_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(func([]byte) error))
+	})
+	return _c
+}
+
+func (_c *Broker_ConsumeMessages_Call) Return(_a0 error) *Broker_ConsumeMessages_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Broker_ConsumeMessages_Call) RunAndReturn(run func(string, func([]byte) error) error) *Broker_ConsumeMessages_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeclareExchange provides a mock function with given fields: name, kind
+func (_m *Broker) DeclareExchange(name string, kind string) error {
+	ret := _m.Called(name, kind)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeclareExchange")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(name, kind)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Broker_DeclareExchange_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeclareExchange'
+type Broker_DeclareExchange_Call struct {
+	*mock.Call
+}
+
+// DeclareExchange is a helper method to define mock.On call
+//   - name string
+//   - kind string
+func (_e *Broker_Expecter) DeclareExchange(name interface{}, kind interface{}) *Broker_DeclareExchange_Call {
+	return &Broker_DeclareExchange_Call{Call: _e.mock.On("DeclareExchange", name, kind)}
+}
+
+func (_c *Broker_DeclareExchange_Call) Run(run func(name string, kind string)) *Broker_DeclareExchange_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Broker_DeclareExchange_Call) Return(_a0 error) *Broker_DeclareExchange_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Broker_DeclareExchange_Call) RunAndReturn(run func(string, string) error) *Broker_DeclareExchange_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeclareQueue provides a mock function with given fields: name, args
+func (_m *Broker) DeclareQueue(name string, args amqp.Table) error {
+	ret := _m.Called(name, args)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeclareQueue")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, amqp.Table) error); ok {
+		r0 = rf(name, args)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Broker_DeclareQueue_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeclareQueue'
+type Broker_DeclareQueue_Call struct {
+	*mock.Call
+}
+
+// DeclareQueue is a helper method to define mock.On call
+//   - name string
+//   - args amqp.Table
+func (_e *Broker_Expecter) DeclareQueue(name interface{}, args interface{}) *Broker_DeclareQueue_Call {
+	return &Broker_DeclareQueue_Call{Call: _e.mock.On("DeclareQueue", name, args)}
+}
+
+func (_c *Broker_DeclareQueue_Call) Run(run func(name string, args amqp.Table)) *Broker_DeclareQueue_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(amqp.Table))
+	})
+	return _c
+}
+
+func (_c *Broker_DeclareQueue_Call) Return(_a0 error) *Broker_DeclareQueue_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Broker_DeclareQueue_Call) RunAndReturn(run func(string, amqp.Table) error) *Broker_DeclareQueue_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Publish provides a mock function with given fields: ctx, exchange, routingKey, msg
+func (_m *Broker) Publish(ctx context.Context, exchange string, routingKey string, msg amqp.Publishing) error {
+	ret := _m.Called(ctx, exchange, routingKey, msg)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Publish")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, amqp.Publishing) error); ok {
+		r0 = rf(ctx, exchange, routingKey, msg)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Broker_Publish_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Publish'
+type Broker_Publish_Call struct {
+	*mock.Call
+}
+
+// Publish is a helper method to define mock.On call
+//   - ctx context.Context
+//   - exchange string
+//   - routingKey string
+//   - msg amqp.Publishing
+func (_e *Broker_Expecter) Publish(ctx interface{}, exchange interface{}, routingKey interface{}, msg interface{}) *Broker_Publish_Call {
+	return &Broker_Publish_Call{Call: _e.mock.On("Publish", ctx, exchange, routingKey, msg)}
+}
+
+func (_c *Broker_Publish_Call) Run(run func(ctx context.Context, exchange string, routingKey string, msg amqp.Publishing)) *Broker_Publish_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(amqp.Publishing))
+	})
+	return _c
+}
+
+func (_c *Broker_Publish_Call) Return(_a0 error) *Broker_Publish_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Broker_Publish_Call) RunAndReturn(run func(context.Context, string, string, amqp.Publishing) error) *Broker_Publish_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PublishAfter provides a mock function with given fields: ctx, exchange, routingKey, msg, delay
+func (_m *Broker) PublishAfter(ctx context.Context, exchange string, routingKey string, msg amqp.Publishing, delay time.Duration) error {
+	ret := _m.Called(ctx, exchange, routingKey, msg, delay)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PublishAfter")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, amqp.Publishing, time.Duration) error); ok {
+		r0 = rf(ctx, exchange, routingKey, msg, delay)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Broker_PublishAfter_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PublishAfter'
+type Broker_PublishAfter_Call struct {
+	*mock.Call
+}
+
+// PublishAfter is a helper method to define mock.On call
+//   - ctx context.Context
+//   - exchange string
+//   - routingKey string
+//   - msg amqp.Publishing
+//   - delay time.Duration
+func (_e *Broker_Expecter) PublishAfter(ctx interface{}, exchange interface{}, routingKey interface{}, msg interface{}, delay interface{}) *Broker_PublishAfter_Call {
+	return &Broker_PublishAfter_Call{Call: _e.mock.On("PublishAfter", ctx, exchange, routingKey, msg, delay)}
+}
+
+func (_c *Broker_PublishAfter_Call) Run(run func(ctx context.Context, exchange string, routingKey string, msg amqp.Publishing, delay time.Duration)) *Broker_PublishAfter_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(amqp.Publishing), args[4].(time.Duration))
+	})
+	return _c
+}
+
+func (_c *Broker_PublishAfter_Call) Return(_a0 error) *Broker_PublishAfter_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Broker_PublishAfter_Call) RunAndReturn(run func(context.Context, string, string, amqp.Publishing, time.Duration) error) *Broker_PublishAfter_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PublishAt provides a mock function with given fields: ctx, exchange, routingKey, msg, at
+func (_m *Broker) PublishAt(ctx context.Context, exchange string, routingKey string, msg amqp.Publishing, at time.Time) error {
+	ret := _m.Called(ctx, exchange, routingKey, msg, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PublishAt")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, amqp.Publishing, time.Time) error); ok {
+		r0 = rf(ctx, exchange, routingKey, msg, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Broker_PublishAt_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PublishAt'
+type Broker_PublishAt_Call struct {
+	*mock.Call
+}
+
+// PublishAt is a helper method to define mock.On call
+//   - ctx context.Context
+//   - exchange string
+//   - routingKey string
+//   - msg amqp.Publishing
+//   - at time.Time
+func (_e *Broker_Expecter) PublishAt(ctx interface{}, exchange interface{}, routingKey interface{}, msg interface{}, at interface{}) *Broker_PublishAt_Call {
+	return &Broker_PublishAt_Call{Call: _e.mock.On("PublishAt", ctx, exchange, routingKey, msg, at)}
+}
+
+func (_c *Broker_PublishAt_Call) Run(run func(ctx context.Context, exchange string, routingKey string, msg amqp.Publishing, at time.Time)) *Broker_PublishAt_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(amqp.Publishing), args[4].(time.Time))
+	})
+	return _c
+}
+
+func (_c *Broker_PublishAt_Call) Return(_a0 error) *Broker_PublishAt_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Broker_PublishAt_Call) RunAndReturn(run func(context.Context, string, string, amqp.Publishing, time.Time) error) *Broker_PublishAt_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PublishConfirmed provides a mock function with given fields: ctx, exchange, routingKey, msg
+func (_m *Broker) PublishConfirmed(ctx context.Context, exchange string, routingKey string, msg amqp.Publishing) error {
+	ret := _m.Called(ctx, exchange, routingKey, msg)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PublishConfirmed")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, amqp.Publishing) error); ok {
+		r0 = rf(ctx, exchange, routingKey, msg)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Broker_PublishConfirmed_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PublishConfirmed'
+type Broker_PublishConfirmed_Call struct {
+	*mock.Call
+}
+
+// PublishConfirmed is a helper method to define mock.On call
+//   - ctx context.Context
+//   - exchange string
+//   - routingKey string
+//   - msg amqp.Publishing
+func (_e *Broker_Expecter) PublishConfirmed(ctx interface{}, exchange interface{}, routingKey interface{}, msg interface{}) *Broker_PublishConfirmed_Call {
+	return &Broker_PublishConfirmed_Call{Call: _e.mock.On("PublishConfirmed", ctx, exchange, routingKey, msg)}
+}
+
+func (_c *Broker_PublishConfirmed_Call) Run(run func(ctx context.Context, exchange string, routingKey string, msg amqp.Publishing)) *Broker_PublishConfirmed_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(amqp.Publishing))
+	})
+	return _c
+}
+
+func (_c *Broker_PublishConfirmed_Call) Return(_a0 error) *Broker_PublishConfirmed_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Broker_PublishConfirmed_Call) RunAndReturn(run func(context.Context, string, string, amqp.Publishing) error) *Broker_PublishConfirmed_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PublishMessage provides a mock function with given fields: exchange, routingKey, body
+func (_m *Broker) PublishMessage(exchange string, routingKey string, body []byte) error {
+	ret := _m.Called(exchange, routingKey, body)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PublishMessage")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, []byte) error); ok {
+		r0 = rf(exchange, routingKey, body)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Broker_PublishMessage_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PublishMessage'
+type Broker_PublishMessage_Call struct {
+	*mock.Call
+}
+
+// PublishMessage is a helper method to define mock.On call
+//   - exchange string
+//   - routingKey string
+//   - body []byte
+func (_e *Broker_Expecter) PublishMessage(exchange interface{}, routingKey interface{}, body interface{}) *Broker_PublishMessage_Call {
+	return &Broker_PublishMessage_Call{Call: _e.mock.On("PublishMessage", exchange, routingKey, body)}
+}
+
+func (_c *Broker_PublishMessage_Call) Run(run func(exchange string, routingKey string, body []byte)) *Broker_PublishMessage_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string), args[2].([]byte))
+	})
+	return _c
+}
+
+func (_c *Broker_PublishMessage_Call) Return(_a0 error) *Broker_PublishMessage_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Broker_PublishMessage_Call) RunAndReturn(run func(string, string, []byte) error) *Broker_PublishMessage_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ServeRPC provides a mock function with given fields: queue, handler
+func (_m *Broker) ServeRPC(queue string, handler func(context.Context, []byte) ([]byte, error)) error {
+	ret := _m.Called(queue, handler)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ServeRPC")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, func(context.Context, []byte) ([]byte, error)) error); ok {
+		r0 = rf(queue, handler)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Broker_ServeRPC_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ServeRPC'
+type Broker_ServeRPC_Call struct {
+	*mock.Call
+}
+
+// ServeRPC is a helper method to define mock.On call
+//   - queue string
+//   - handler func(context.Context , []byte)([]byte , error)
+func (_e *Broker_Expecter) ServeRPC(queue interface{}, handler interface{}) *Broker_ServeRPC_Call {
+	return &Broker_ServeRPC_Call{Call: _e.mock.On("ServeRPC", queue, handler)}
+}
+
+func (_c *Broker_ServeRPC_Call) Run(run func(queue string, handler func(context.Context, []byte) ([]byte, error))) *Broker_ServeRPC_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(func(context.Context, []byte) ([]byte, error)))
+	})
+	return _c
+}
+
+func (_c *Broker_ServeRPC_Call) Return(_a0 error) *Broker_ServeRPC_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Broker_ServeRPC_Call) RunAndReturn(run func(string, func(context.Context, []byte) ([]byte, error)) error) *Broker_ServeRPC_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewBroker creates a new instance of Broker. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewBroker(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Broker {
+	mock := &Broker{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
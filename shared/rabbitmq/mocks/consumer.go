@@ -0,0 +1,191 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	amqp "github.com/streadway/amqp"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Consumer is an autogenerated mock type for the Consumer type
+type Consumer struct {
+	mock.Mock
+}
+
+type Consumer_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *Consumer) EXPECT() *Consumer_Expecter {
+	return &Consumer_Expecter{mock: &_m.Mock}
+}
+
+// Consume provides a mock function with given fields: queue, consumer
+func (_m *Consumer) Consume(queue string, consumer string) (<-chan amqp.Delivery, error) {
+	ret := _m.Called(queue, consumer)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Consume")
+	}
+
+	var r0 <-chan amqp.Delivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (<-chan amqp.Delivery, error)); ok {
+		return rf(queue, consumer)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) <-chan amqp.Delivery); ok {
+		r0 = rf(queue, consumer)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan amqp.Delivery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(queue, consumer)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Consumer_Consume_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Consume'
+type Consumer_Consume_Call struct {
+	*mock.Call
+}
+
+// Consume is a helper method to define mock.On call
+//   - queue string
+//   - consumer string
+func (_e *Consumer_Expecter) Consume(queue interface{}, consumer interface{}) *Consumer_Consume_Call {
+	return &Consumer_Consume_Call{Call: _e.mock.On("Consume", queue, consumer)}
+}
+
+func (_c *Consumer_Consume_Call) Run(run func(queue string, consumer string)) *Consumer_Consume_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Consumer_Consume_Call) Return(_a0 <-chan amqp.Delivery, _a1 error) *Consumer_Consume_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Consumer_Consume_Call) RunAndReturn(run func(string, string) (<-chan amqp.Delivery, error)) *Consumer_Consume_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ConsumeMessages provides a mock function with given fields: queue, handler
+func (_m *Consumer) ConsumeMessages(queue string, handler func([]byte) error) error {
+	ret := _m.Called(queue, handler)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConsumeMessages")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, func([]byte) error) error); ok {
+		r0 = rf(queue, handler)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Consumer_ConsumeMessages_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ConsumeMessages'
+type Consumer_ConsumeMessages_Call struct {
+	*mock.Call
+}
+
+// ConsumeMessages is a helper method to define mock.On call
+//   - queue string
+//   - handler func([]byte) error
+func (_e *Consumer_Expecter) ConsumeMessages(queue interface{}, handler interface{}) *Consumer_ConsumeMessages_Call {
+	return &Consumer_ConsumeMessages_Call{Call: _e.mock.On("ConsumeMessages", queue, handler)}
+}
+
+func (_c *Consumer_ConsumeMessages_Call) Run(run func(queue string, handler func([]byte) error)) *Consumer_ConsumeMessages_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(func([]byte) error))
+	})
+	return _c
+}
+
+func (_c *Consumer_ConsumeMessages_Call) Return(_a0 error) *Consumer_ConsumeMessages_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Consumer_ConsumeMessages_Call) RunAndReturn(run func(string, func([]byte) error) error) *Consumer_ConsumeMessages_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ServeRPC provides a mock function with given fields: queue, handler
+func (_m *Consumer) ServeRPC(queue string, handler func(context.Context, []byte) ([]byte, error)) error {
+	ret := _m.Called(queue, handler)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ServeRPC")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, func(context.Context, []byte) ([]byte, error)) error); ok {
+		r0 = rf(queue, handler)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Consumer_ServeRPC_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ServeRPC'
+type Consumer_ServeRPC_Call struct {
+	*mock.Call
+}
+
+// ServeRPC is a helper method to define mock.On call
+//   - queue string
+//   - handler func(context.Context , []byte)([]byte , error)
+func (_e *Consumer_Expecter) ServeRPC(queue interface{}, handler interface{}) *Consumer_ServeRPC_Call {
+	return &Consumer_ServeRPC_Call{Call: _e.mock.On("ServeRPC", queue, handler)}
+}
+
+func (_c *Consumer_ServeRPC_Call) Run(run func(queue string, handler func(context.Context, []byte) ([]byte, error))) *Consumer_ServeRPC_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(func(context.Context, []byte) ([]byte, error)))
+	})
+	return _c
+}
+
+func (_c *Consumer_ServeRPC_Call) Return(_a0 error) *Consumer_ServeRPC_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Consumer_ServeRPC_Call) RunAndReturn(run func(string, func(context.Context, []byte) ([]byte, error)) error) *Consumer_ServeRPC_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewConsumer creates a new instance of Consumer. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewConsumer(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Consumer {
+	mock := &Consumer{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,347 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	amqp "github.com/streadway/amqp"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// Publisher is an autogenerated mock type for the Publisher type
+type Publisher struct {
+	mock.Mock
+}
+
+type Publisher_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *Publisher) EXPECT() *Publisher_Expecter {
+	return &Publisher_Expecter{mock: &_m.Mock}
+}
+
+// Call provides a mock function with given fields: ctx, exchange, routingKey, req
+func (_m *Publisher) Call(ctx context.Context, exchange string, routingKey string, req []byte) ([]byte, error) {
+	ret := _m.Called(ctx, exchange, routingKey, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Call")
+	}
+
+	var r0 []byte
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []byte) ([]byte, error)); ok {
+		return rf(ctx, exchange, routingKey, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []byte) []byte); ok {
+		r0 = rf(ctx, exchange, routingKey, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, []byte) error); ok {
+		r1 = rf(ctx, exchange, routingKey, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Publisher_Call_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Call'
+type Publisher_Call_Call struct {
+	*mock.Call
+}
+
+// Call is a helper method to define mock.On call
+//   - ctx context.Context
+//   - exchange string
+//   - routingKey string
+//   - req []byte
+func (_e *Publisher_Expecter) Call(ctx interface{}, exchange interface{}, routingKey interface{}, req interface{}) *Publisher_Call_Call {
+	return &Publisher_Call_Call{Call: _e.mock.On("Call", ctx, exchange, routingKey, req)}
+}
+
+func (_c *Publisher_Call_Call) Run(run func(ctx context.Context, exchange string, routingKey string, req []byte)) *Publisher_Call_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].([]byte))
+	})
+	return _c
+}
+
+func (_c *Publisher_Call_Call) Return(_a0 []byte, _a1 error) *Publisher_Call_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Publisher_Call_Call) RunAndReturn(run func(context.Context, string, string, []byte) ([]byte, error)) *Publisher_Call_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Publish provides a mock function with given fields: ctx, exchange, routingKey, msg
+func (_m *Publisher) Publish(ctx context.Context, exchange string, routingKey string, msg amqp.Publishing) error {
+	ret := _m.Called(ctx, exchange, routingKey, msg)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Publish")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, amqp.Publishing) error); ok {
+		r0 = rf(ctx, exchange, routingKey, msg)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Publisher_Publish_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Publish'
+type Publisher_Publish_Call struct {
+	*mock.Call
+}
+
+// Publish is a helper method to define mock.On call
+//   - ctx context.Context
+//   - exchange string
+//   - routingKey string
+//   - msg amqp.Publishing
+func (_e *Publisher_Expecter) Publish(ctx interface{}, exchange interface{}, routingKey interface{}, msg interface{}) *Publisher_Publish_Call {
+	return &Publisher_Publish_Call{Call: _e.mock.On("Publish", ctx, exchange, routingKey, msg)}
+}
+
+func (_c *Publisher_Publish_Call) Run(run func(ctx context.Context, exchange string, routingKey string, msg amqp.Publishing)) *Publisher_Publish_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(amqp.Publishing))
+	})
+	return _c
+}
+
+func (_c *Publisher_Publish_Call) Return(_a0 error) *Publisher_Publish_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Publisher_Publish_Call) RunAndReturn(run func(context.Context, string, string, amqp.Publishing) error) *Publisher_Publish_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PublishAfter provides a mock function with given fields: ctx, exchange, routingKey, msg, delay
+func (_m *Publisher) PublishAfter(ctx context.Context, exchange string, routingKey string, msg amqp.Publishing, delay time.Duration) error {
+	ret := _m.Called(ctx, exchange, routingKey, msg, delay)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PublishAfter")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, amqp.Publishing, time.Duration) error); ok {
+		r0 = rf(ctx, exchange, routingKey, msg, delay)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Publisher_PublishAfter_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PublishAfter'
+type Publisher_PublishAfter_Call struct {
+	*mock.Call
+}
+
+// PublishAfter is a helper method to define mock.On call
+//   - ctx context.Context
+//   - exchange string
+//   - routingKey string
+//   - msg amqp.Publishing
+//   - delay time.Duration
+func (_e *Publisher_Expecter) PublishAfter(ctx interface{}, exchange interface{}, routingKey interface{}, msg interface{}, delay interface{}) *Publisher_PublishAfter_Call {
+	return &Publisher_PublishAfter_Call{Call: _e.mock.On("PublishAfter", ctx, exchange, routingKey, msg, delay)}
+}
+
+func (_c *Publisher_PublishAfter_Call) Run(run func(ctx context.Context, exchange string, routingKey string, msg amqp.Publishing, delay time.Duration)) *Publisher_PublishAfter_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(amqp.Publishing), args[4].(time.Duration))
+	})
+	return _c
+}
+
+func (_c *Publisher_PublishAfter_Call) Return(_a0 error) *Publisher_PublishAfter_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Publisher_PublishAfter_Call) RunAndReturn(run func(context.Context, string, string, amqp.Publishing, time.Duration) error) *Publisher_PublishAfter_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PublishAt provides a mock function with given fields: ctx, exchange, routingKey, msg, at
+func (_m *Publisher) PublishAt(ctx context.Context, exchange string, routingKey string, msg amqp.Publishing, at time.Time) error {
+	ret := _m.Called(ctx, exchange, routingKey, msg, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PublishAt")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, amqp.Publishing, time.Time) error); ok {
+		r0 = rf(ctx, exchange, routingKey, msg, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Publisher_PublishAt_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PublishAt'
+type Publisher_PublishAt_Call struct {
+	*mock.Call
+}
+
+// PublishAt is a helper method to define mock.On call
+//   - ctx context.Context
+//   - exchange string
+//   - routingKey string
+//   - msg amqp.Publishing
+//   - at time.Time
+func (_e *Publisher_Expecter) PublishAt(ctx interface{}, exchange interface{}, routingKey interface{}, msg interface{}, at interface{}) *Publisher_PublishAt_Call {
+	return &Publisher_PublishAt_Call{Call: _e.mock.On("PublishAt", ctx, exchange, routingKey, msg, at)}
+}
+
+func (_c *Publisher_PublishAt_Call) Run(run func(ctx context.Context, exchange string, routingKey string, msg amqp.Publishing, at time.Time)) *Publisher_PublishAt_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(amqp.Publishing), args[4].(time.Time))
+	})
+	return _c
+}
+
+func (_c *Publisher_PublishAt_Call) Return(_a0 error) *Publisher_PublishAt_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Publisher_PublishAt_Call) RunAndReturn(run func(context.Context, string, string, amqp.Publishing, time.Time) error) *Publisher_PublishAt_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PublishConfirmed provides a mock function with given fields: ctx, exchange, routingKey, msg
+func (_m *Publisher) PublishConfirmed(ctx context.Context, exchange string, routingKey string, msg amqp.Publishing) error {
+	ret := _m.Called(ctx, exchange, routingKey, msg)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PublishConfirmed")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, amqp.Publishing) error); ok {
+		r0 = rf(ctx, exchange, routingKey, msg)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Publisher_PublishConfirmed_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PublishConfirmed'
+type Publisher_PublishConfirmed_Call struct {
+	*mock.Call
+}
+
+// PublishConfirmed is a helper method to define mock.On call
+//   - ctx context.Context
+//   - exchange string
+//   - routingKey string
+//   - msg amqp.Publishing
+func (_e *Publisher_Expecter) PublishConfirmed(ctx interface{}, exchange interface{}, routingKey interface{}, msg interface{}) *Publisher_PublishConfirmed_Call {
+	return &Publisher_PublishConfirmed_Call{Call: _e.mock.On("PublishConfirmed", ctx, exchange, routingKey, msg)}
+}
+
+func (_c *Publisher_PublishConfirmed_Call) Run(run func(ctx context.Context, exchange string, routingKey string, msg amqp.Publishing)) *Publisher_PublishConfirmed_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(amqp.Publishing))
+	})
+	return _c
+}
+
+func (_c *Publisher_PublishConfirmed_Call) Return(_a0 error) *Publisher_PublishConfirmed_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Publisher_PublishConfirmed_Call) RunAndReturn(run func(context.Context, string, string, amqp.Publishing) error) *Publisher_PublishConfirmed_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PublishMessage provides a mock function with given fields: exchange, routingKey, body
+func (_m *Publisher) PublishMessage(exchange string, routingKey string, body []byte) error {
+	ret := _m.Called(exchange, routingKey, body)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PublishMessage")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, []byte) error); ok {
+		r0 = rf(exchange, routingKey, body)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Publisher_PublishMessage_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PublishMessage'
+type Publisher_PublishMessage_Call struct {
+	*mock.Call
+}
+
+// PublishMessage is a helper method to define mock.On call
+//   - exchange string
+//   - routingKey string
+//   - body []byte
+func (_e *Publisher_Expecter) PublishMessage(exchange interface{}, routingKey interface{}, body interface{}) *Publisher_PublishMessage_Call {
+	return &Publisher_PublishMessage_Call{Call: _e.mock.On("PublishMessage", exchange, routingKey, body)}
+}
+
+func (_c *Publisher_PublishMessage_Call) Run(run func(exchange string, routingKey string, body []byte)) *Publisher_PublishMessage_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string), args[2].([]byte))
+	})
+	return _c
+}
+
+func (_c *Publisher_PublishMessage_Call) Return(_a0 error) *Publisher_PublishMessage_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Publisher_PublishMessage_Call) RunAndReturn(run func(string, string, []byte) error) *Publisher_PublishMessage_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewPublisher creates a new instance of Publisher. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewPublisher(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Publisher {
+	mock := &Publisher{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -1,14 +1,58 @@
 package rabbitmq
 
 import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
 	"github.com/streadway/amqp"
 	"go.uber.org/zap"
 )
 
+// Publisher sends messages, including RPC requests.
+type Publisher interface {
+	PublishMessage(exchange, routingKey string, body []byte) error
+	Publish(ctx context.Context, exchange, routingKey string, msg amqp.Publishing) error
+	PublishConfirmed(ctx context.Context, exchange, routingKey string, msg amqp.Publishing) error
+	PublishAfter(ctx context.Context, exchange, routingKey string, msg amqp.Publishing, delay time.Duration) error
+	PublishAt(ctx context.Context, exchange, routingKey string, msg amqp.Publishing, at time.Time) error
+	Call(ctx context.Context, exchange, routingKey string, req []byte) ([]byte, error)
+}
+
+// Consumer receives messages, including RPC requests.
+type Consumer interface {
+	ConsumeMessages(queue string, handler func([]byte) error) error
+	Consume(queue, consumer string) (<-chan amqp.Delivery, error)
+	ServeRPC(queue string, handler func(ctx context.Context, req []byte) ([]byte, error)) error
+}
+
+// Broker is the full set of messaging operations services depend on. It is
+// implemented by RabbitMQ and by the in-process MemoryBroker used in tests.
+type Broker interface {
+	Publisher
+	Consumer
+	DeclareExchange(name, kind string) error
+	DeclareQueue(name string, args amqp.Table) error
+	BindQueue(queue, routingKey, exchange string, args amqp.Table) error
+	Close()
+}
+
+var _ Broker = (*RabbitMQ)(nil)
+
 type RabbitMQ struct {
 	conn    *amqp.Connection
 	channel *amqp.Channel
 	logger  *zap.Logger
+
+	// Lazily opened channel in confirm mode, see PublishConfirmed.
+	confirmMu  sync.Mutex
+	confirmCh  *amqp.Channel
+	confirms   chan amqp.Confirmation
+	confirmSeq uint64
+
+	rpc   rpcClient
+	delay delayQueues
 }
 
 func New(url string, logger *zap.Logger) (*RabbitMQ, error) {
@@ -74,7 +118,88 @@ func (r *RabbitMQ) ConsumeMessages(queue string, handler func([]byte) error) err
 	return nil
 }
 
+// Publish sends a fully specified message, including its properties and headers.
+func (r *RabbitMQ) Publish(ctx context.Context, exchange, routingKey string, msg amqp.Publishing) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.channel.Publish(exchange, routingKey, false, false, msg)
+}
+
+// Consume delivers messages from queue with manual acknowledgement. The caller
+// must Ack, Nack or Reject every delivery.
+func (r *RabbitMQ) Consume(queue, consumer string) (<-chan amqp.Delivery, error) {
+	return r.channel.Consume(queue, consumer, false, false, false, false, nil)
+}
+
+// PublishConfirmed publishes msg and waits until the broker confirms it. Messages
+// are sent one at a time on a dedicated channel in confirm mode.
+func (r *RabbitMQ) PublishConfirmed(ctx context.Context, exchange, routingKey string, msg amqp.Publishing) error {
+	r.confirmMu.Lock()
+	defer r.confirmMu.Unlock()
+
+	if r.confirmCh == nil {
+		ch, err := r.conn.Channel()
+		if err != nil {
+			return err
+		}
+		if err := ch.Confirm(false); err != nil {
+			ch.Close()
+			return err
+		}
+		r.confirmCh = ch
+		r.confirms = ch.NotifyPublish(make(chan amqp.Confirmation, 1))
+		r.confirmSeq = 0
+	}
+
+	if err := r.confirmCh.Publish(exchange, routingKey, false, false, msg); err != nil {
+		return err
+	}
+	r.confirmSeq++
+
+	for {
+		select {
+		case c, ok := <-r.confirms:
+			if !ok {
+				r.confirmCh = nil
+				return fmt.Errorf("confirm channel closed")
+			}
+			// Skip confirmations left over from publishes whose caller gave up.
+			if c.DeliveryTag < r.confirmSeq {
+				continue
+			}
+			if !c.Ack {
+				return fmt.Errorf("message to %s/%s was nacked by the broker", exchange, routingKey)
+			}
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (r *RabbitMQ) DeclareExchange(name, kind string) error {
+	return r.channel.ExchangeDeclare(name, kind, true, false, false, false, nil)
+}
+
+func (r *RabbitMQ) DeclareQueue(name string, args amqp.Table) error {
+	_, err := r.channel.QueueDeclare(name, true, false, false, false, args)
+	return err
+}
+
+func (r *RabbitMQ) BindQueue(queue, routingKey, exchange string, args amqp.Table) error {
+	return r.channel.QueueBind(queue, routingKey, exchange, false, args)
+}
+
 func (r *RabbitMQ) Close() {
+	if r.confirmCh != nil {
+		r.confirmCh.Close()
+	}
+	r.rpc.mu.Lock()
+	if r.rpc.ch != nil {
+		r.rpc.ch.Close()
+	}
+	r.rpc.mu.Unlock()
 	r.channel.Close()
 	r.conn.Close()
 }
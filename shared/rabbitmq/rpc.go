@@ -0,0 +1,209 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/streadway/amqp"
+	"go.uber.org/zap"
+)
+
+const (
+	// Pseudo-queue for RabbitMQ's direct reply-to feature.
+	directReplyTo = "amq.rabbitmq.reply-to"
+
+	headerRPCError = "x-rpc-error"
+
+	defaultCallTimeout = 10 * time.Second
+)
+
+var ErrRPCChannelClosed = errors.New("rpc channel closed")
+
+// RemoteError is returned by Call when the server's handler failed.
+type RemoteError struct {
+	Message string
+}
+
+func (e *RemoteError) Error() string {
+	return "remote error: " + e.Message
+}
+
+// rpcClient owns the channel used for Call. Replies arrive on the direct
+// reply-to pseudo-queue of that channel and are matched by correlation ID.
+type rpcClient struct {
+	mu      sync.Mutex
+	ch      *amqp.Channel
+	pending map[string]chan amqp.Delivery
+}
+
+// Call publishes req and waits for the reply. If ctx has no deadline the call
+// times out after 10 seconds; the request expires in the queue at the same time.
+func (r *RabbitMQ) Call(ctx context.Context, exchange, routingKey string, req []byte) ([]byte, error) {
+	ctx, cancel := withCallTimeout(ctx)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+
+	correlationID := uuid.NewString()
+	replies := make(chan amqp.Delivery, 1)
+
+	ch, err := r.rpcChannel(correlationID, replies)
+	if err != nil {
+		return nil, err
+	}
+	defer r.rpc.forget(correlationID)
+
+	err = ch.Publish(exchange, routingKey, false, false, amqp.Publishing{
+		ContentType:   ContentTypeJSON,
+		CorrelationId: correlationID,
+		ReplyTo:       directReplyTo,
+		Expiration:    expiration(deadline),
+		Body:          req,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case d, ok := <-replies:
+		if !ok {
+			return nil, ErrRPCChannelClosed
+		}
+		return replyResult(d)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func withCallTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, defaultCallTimeout)
+}
+
+// expiration returns the per-message TTL that makes a request expire at deadline.
+func expiration(deadline time.Time) string {
+	return strconv.FormatInt(max(time.Until(deadline).Milliseconds(), 1), 10)
+}
+
+// rpcChannel registers a pending call and returns the RPC channel, opening it
+// and starting the reply dispatcher on first use.
+func (r *RabbitMQ) rpcChannel(correlationID string, replies chan amqp.Delivery) (*amqp.Channel, error) {
+	r.rpc.mu.Lock()
+	defer r.rpc.mu.Unlock()
+
+	if r.rpc.ch == nil {
+		ch, err := r.conn.Channel()
+		if err != nil {
+			return nil, err
+		}
+		// Direct reply-to requires consuming in no-ack mode before publishing.
+		msgs, err := ch.Consume(directReplyTo, "", true, false, false, false, nil)
+		if err != nil {
+			ch.Close()
+			return nil, err
+		}
+		r.rpc.ch = ch
+		r.rpc.pending = make(map[string]chan amqp.Delivery)
+		go r.dispatchReplies(ch, msgs)
+	}
+
+	r.rpc.pending[correlationID] = replies
+	return r.rpc.ch, nil
+}
+
+func (r *RabbitMQ) dispatchReplies(ch *amqp.Channel, msgs <-chan amqp.Delivery) {
+	for d := range msgs {
+		r.rpc.mu.Lock()
+		replies, ok := r.rpc.pending[d.CorrelationId]
+		delete(r.rpc.pending, d.CorrelationId)
+		r.rpc.mu.Unlock()
+
+		if !ok {
+			r.logger.Warn("Dropping reply for unknown or expired call", zap.String("correlationId", d.CorrelationId))
+			continue
+		}
+		replies <- d
+	}
+
+	// The channel is gone: fail every waiting call and let the next Call reopen it.
+	r.rpc.mu.Lock()
+	defer r.rpc.mu.Unlock()
+	if r.rpc.ch == ch {
+		for id, replies := range r.rpc.pending {
+			close(replies)
+			delete(r.rpc.pending, id)
+		}
+		r.rpc.ch = nil
+	}
+}
+
+func (c *rpcClient) forget(correlationID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pending, correlationID)
+}
+
+// ServeRPC answers calls arriving on queue with the return value of handler.
+// A handler error is sent back to the caller as a RemoteError. The handler's
+// context expires when the caller stops waiting.
+func (r *RabbitMQ) ServeRPC(queue string, handler func(ctx context.Context, req []byte) ([]byte, error)) error {
+	msgs, err := r.Consume(queue, "")
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for d := range msgs {
+			serveCall(r, r.logger, d, handler)
+		}
+	}()
+
+	r.logger.Info("Serving RPC calls", zap.String("queue", queue))
+	return nil
+}
+
+func serveCall(b Broker, logger *zap.Logger, d amqp.Delivery, handler func(ctx context.Context, req []byte) ([]byte, error)) {
+	defer d.Ack(false)
+
+	if d.ReplyTo == "" {
+		logger.Warn("Dropping RPC request without reply-to", zap.String("correlationId", d.CorrelationId))
+		return
+	}
+
+	ctx := context.Background()
+	if ms, err := strconv.ParseInt(d.Expiration, 10, 64); err == nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(ms)*time.Millisecond)
+		defer cancel()
+	}
+
+	reply := amqp.Publishing{
+		ContentType:   ContentTypeJSON,
+		CorrelationId: d.CorrelationId,
+	}
+	body, err := handler(ctx, d.Body)
+	if err != nil {
+		logger.Error("RPC handler failed", zap.String("correlationId", d.CorrelationId), zap.Error(err))
+		reply.Headers = amqp.Table{headerRPCError: err.Error()}
+	} else {
+		reply.Body = body
+	}
+
+	if err := b.Publish(context.Background(), "", d.ReplyTo, reply); err != nil {
+		logger.Error("Failed to send RPC reply", zap.String("correlationId", d.CorrelationId), zap.Error(fmt.Errorf("reply to %s: %w", d.ReplyTo, err)))
+	}
+}
+
+// replyResult converts an RPC reply delivery into the value returned by Call.
+func replyResult(d amqp.Delivery) ([]byte, error) {
+	if msg, ok := d.Headers[headerRPCError].(string); ok {
+		return nil, &RemoteError{Message: msg}
+	}
+	return d.Body, nil
+}
@@ -0,0 +1,325 @@
+package saga
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/MuxSphere/microkit/shared/database"
+	apperrors "github.com/MuxSphere/microkit/shared/errors"
+	"github.com/MuxSphere/microkit/shared/rabbitmq"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/streadway/amqp"
+	"go.uber.org/zap"
+)
+
+const (
+	headerSagaID     = "x-saga-id"
+	headerSagaStep   = "x-saga-step"
+	headerCompensate = "x-saga-compensate"
+	headerSagaError  = "x-saga-error"
+
+	defaultStepTimeout = 30 * time.Second
+)
+
+// Orchestrator runs saga instances. Commands are published over RabbitMQ and
+// participants reply to a shared durable queue, so any replica can pick up a
+// reply and sagas resume after a crash.
+type Orchestrator struct {
+	db         database.DB
+	mq         rabbitmq.Broker
+	logger     *zap.Logger
+	replyQueue string
+
+	mu          sync.RWMutex
+	definitions map[string]Definition
+
+	// SweepInterval is how often timed out steps are looked for.
+	SweepInterval time.Duration
+	// MaxCompensationAttempts is how often a compensation is sent before the
+	// saga is marked as failed.
+	MaxCompensationAttempts int
+}
+
+func New(db database.DB, mq rabbitmq.Broker, logger *zap.Logger, replyQueue string) *Orchestrator {
+	return &Orchestrator{
+		db:                      db,
+		mq:                      mq,
+		logger:                  logger,
+		replyQueue:              replyQueue,
+		definitions:             make(map[string]Definition),
+		SweepInterval:           5 * time.Second,
+		MaxCompensationAttempts: 5,
+	}
+}
+
+func (o *Orchestrator) Register(def Definition) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.definitions[def.Name] = def
+}
+
+func (o *Orchestrator) definition(name string) (Definition, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	def, ok := o.definitions[name]
+	if !ok {
+		return Definition{}, fmt.Errorf("%w: %s", ErrUnknownDefinition, name)
+	}
+	return def, nil
+}
+
+// Start begins a new instance of the named saga with data as its initial
+// payload and returns its ID.
+func (o *Orchestrator) Start(ctx context.Context, name string, data any) (string, error) {
+	def, err := o.definition(name)
+	if err != nil {
+		return "", err
+	}
+	if len(def.Steps) == 0 {
+		return "", fmt.Errorf("saga %s has no steps", name)
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+
+	inst := &Instance{
+		ID:     uuid.NewString(),
+		Name:   name,
+		Status: StatusRunning,
+		Data:   payload,
+	}
+	o.setDeadline(def, inst)
+
+	_, err = o.db.ExecContext(ctx, `
+		INSERT INTO sagas (id, name, status, step, attempts, data, deadline)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		inst.ID, inst.Name, inst.Status, inst.Step, inst.Attempts, string(inst.Data), inst.Deadline)
+	if err != nil {
+		return "", err
+	}
+
+	// If this publish is lost, the step times out and the saga is compensated.
+	if err := o.send(ctx, def, inst); err != nil {
+		o.logger.Warn("Failed to send saga command", zap.String("saga", inst.ID), zap.Error(err))
+	}
+	return inst.ID, nil
+}
+
+// Run consumes replies, resends the current command of sagas left active by a
+// previous run and handles step timeouts until ctx is cancelled.
+func (o *Orchestrator) Run(ctx context.Context) error {
+	if err := o.mq.DeclareQueue(o.replyQueue, nil); err != nil {
+		return err
+	}
+	replies, err := o.mq.Consume(o.replyQueue, "")
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for d := range replies {
+			err := o.handleReply(ctx, d)
+			decision, _ := apperrors.Settle(d, err)
+			if err != nil {
+				o.logger.Error("Failed to handle saga reply", zap.String("correlationId", d.CorrelationId), zap.Stringer("decision", decision), zap.Error(err))
+			}
+		}
+	}()
+
+	if err := o.resume(ctx); err != nil {
+		o.logger.Error("Failed to resume sagas", zap.Error(err))
+	}
+
+	o.logger.Info("Starting saga orchestrator", zap.String("replyQueue", o.replyQueue))
+	ticker := time.NewTicker(o.SweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			o.logger.Info("Saga orchestrator stopped")
+			return nil
+		case <-ticker.C:
+			if err := o.sweep(ctx); err != nil && ctx.Err() == nil {
+				o.logger.Error("Failed to handle saga timeouts", zap.Error(err))
+			}
+		}
+	}
+}
+
+func (o *Orchestrator) handleReply(ctx context.Context, d amqp.Delivery) error {
+	corr, err := parseCorrelation(d.CorrelationId)
+	if err != nil {
+		o.logger.Warn("Dropping reply with unknown correlation", zap.String("correlationId", d.CorrelationId))
+		return nil
+	}
+
+	tx, err := o.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var inst Instance
+	err = tx.GetContext(ctx, &inst, `SELECT `+instanceColumns+` FROM sagas WHERE id = $1 FOR UPDATE`, corr.SagaID)
+	if errors.Is(err, sql.ErrNoRows) {
+		o.logger.Warn("Dropping reply for unknown saga", zap.String("saga", corr.SagaID))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Duplicate and late replies no longer match the current command.
+	if !inst.active() || inst.Step != corr.Step || corr.Compensate != (inst.Status == StatusCompensating) {
+		o.logger.Debug("Ignoring stale saga reply", zap.String("saga", inst.ID), zap.String("correlationId", d.CorrelationId))
+		return nil
+	}
+	def, err := o.definition(inst.Name)
+	if err != nil {
+		return err
+	}
+
+	result, reason := succeeded, ""
+	if msg, ok := d.Headers[headerSagaError].(string); ok {
+		result, reason = failed, msg
+	}
+	send := def.apply(&inst, result, reason, d.Body, o.MaxCompensationAttempts)
+	o.setDeadline(def, &inst)
+	if err := o.save(ctx, tx, &inst); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	o.logger.Info("Saga advanced", zap.String("saga", inst.ID), zap.String("status", inst.Status), zap.Int("step", inst.Step))
+	if send {
+		return o.send(ctx, def, &inst)
+	}
+	return nil
+}
+
+// sweep treats steps whose deadline passed as failed.
+func (o *Orchestrator) sweep(ctx context.Context) error {
+	tx, err := o.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var expired []Instance
+	err = tx.SelectContext(ctx, &expired, `
+		SELECT `+instanceColumns+` FROM sagas
+		WHERE status IN ($1, $2) AND deadline < now()
+		ORDER BY deadline
+		LIMIT 100
+		FOR UPDATE SKIP LOCKED`, StatusRunning, StatusCompensating)
+	if err != nil {
+		return err
+	}
+
+	var resend []Instance
+	for _, inst := range expired {
+		def, err := o.definition(inst.Name)
+		if err != nil {
+			o.logger.Warn("Skipping saga with unknown definition", zap.String("saga", inst.ID), zap.Error(err))
+			continue
+		}
+		o.logger.Warn("Saga step timed out", zap.String("saga", inst.ID), zap.Int("step", inst.Step), zap.String("status", inst.Status))
+		if def.apply(&inst, timedOut, "timed out", nil, o.MaxCompensationAttempts) {
+			resend = append(resend, inst)
+		}
+		o.setDeadline(def, &inst)
+		if err := o.save(ctx, tx, &inst); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for i := range resend {
+		def, _ := o.definition(resend[i].Name)
+		if err := o.send(ctx, def, &resend[i]); err != nil {
+			o.logger.Warn("Failed to send saga command", zap.String("saga", resend[i].ID), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// resume resends the current command of every active saga. Participants must
+// therefore handle commands idempotently, for example with shared/inbox.
+func (o *Orchestrator) resume(ctx context.Context) error {
+	var active []Instance
+	err := o.db.SelectContext(ctx, &active, `
+		SELECT `+instanceColumns+` FROM sagas WHERE status IN ($1, $2)`, StatusRunning, StatusCompensating)
+	if err != nil {
+		return err
+	}
+
+	for i := range active {
+		def, err := o.definition(active[i].Name)
+		if err != nil {
+			continue
+		}
+		if err := o.send(ctx, def, &active[i]); err != nil {
+			return err
+		}
+	}
+	if len(active) > 0 {
+		o.logger.Info("Resumed sagas", zap.Int("count", len(active)))
+	}
+	return nil
+}
+
+func (o *Orchestrator) setDeadline(def Definition, inst *Instance) {
+	if !inst.active() {
+		inst.Deadline = nil
+		return
+	}
+	timeout := def.Steps[inst.Step].Timeout
+	if timeout <= 0 {
+		timeout = defaultStepTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	inst.Deadline = &deadline
+}
+
+func (o *Orchestrator) save(ctx context.Context, exec sqlx.ExecerContext, inst *Instance) error {
+	_, err := exec.ExecContext(ctx, `
+		UPDATE sagas SET status = $2, step = $3, attempts = $4, data = $5, error = $6, deadline = $7, updated_at = now()
+		WHERE id = $1`,
+		inst.ID, inst.Status, inst.Step, inst.Attempts, string(inst.Data), inst.Error, inst.Deadline)
+	return err
+}
+
+// send publishes the command for the current step of inst.
+func (o *Orchestrator) send(ctx context.Context, def Definition, inst *Instance) error {
+	step := def.Steps[inst.Step]
+	compensate := inst.Status == StatusCompensating
+	endpoint := step.Action
+	if compensate {
+		endpoint = *step.Compensation
+	}
+
+	corr := correlation{SagaID: inst.ID, Step: inst.Step, Compensate: compensate}
+	return o.mq.Publish(ctx, endpoint.Exchange, endpoint.RoutingKey, amqp.Publishing{
+		ContentType:   rabbitmq.ContentTypeJSON,
+		DeliveryMode:  amqp.Persistent,
+		CorrelationId: corr.String(),
+		ReplyTo:       o.replyQueue,
+		Headers: amqp.Table{
+			headerSagaID:     inst.ID,
+			headerSagaStep:   step.Name,
+			headerCompensate: compensate,
+		},
+		Body: inst.Data,
+	})
+}
@@ -0,0 +1,61 @@
+package saga
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/MuxSphere/microkit/shared/rabbitmq"
+	"github.com/streadway/amqp"
+	"go.uber.org/zap"
+)
+
+// Command is what a participant receives for a saga step.
+type Command struct {
+	SagaID     string
+	Step       string
+	Compensate bool
+	Data       json.RawMessage
+}
+
+// Serve handles saga commands arriving on queue and replies to the
+// orchestrator. Returning an error fails the step (or the compensation); a
+// non-empty result replaces the saga data. Commands can be delivered more than
+// once, so handlers must be idempotent.
+func Serve(mq rabbitmq.Broker, logger *zap.Logger, queue string, handler func(ctx context.Context, cmd Command) (json.RawMessage, error)) error {
+	msgs, err := mq.Consume(queue, "")
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for d := range msgs {
+			cmd := Command{Data: d.Body}
+			cmd.SagaID, _ = d.Headers[headerSagaID].(string)
+			cmd.Step, _ = d.Headers[headerSagaStep].(string)
+			cmd.Compensate, _ = d.Headers[headerCompensate].(bool)
+
+			reply := amqp.Publishing{
+				ContentType:   rabbitmq.ContentTypeJSON,
+				DeliveryMode:  amqp.Persistent,
+				CorrelationId: d.CorrelationId,
+			}
+			result, err := handler(context.Background(), cmd)
+			if err != nil {
+				logger.Warn("Saga command failed", zap.String("saga", cmd.SagaID), zap.String("step", cmd.Step), zap.Bool("compensate", cmd.Compensate), zap.Error(err))
+				reply.Headers = amqp.Table{headerSagaError: err.Error()}
+			} else {
+				reply.Body = result
+			}
+
+			if err := mq.Publish(context.Background(), "", d.ReplyTo, reply); err != nil {
+				logger.Error("Failed to reply to saga command", zap.String("saga", cmd.SagaID), zap.Error(err))
+				d.Reject(true)
+				continue
+			}
+			d.Ack(false)
+		}
+	}()
+
+	logger.Info("Serving saga commands", zap.String("queue", queue))
+	return nil
+}
@@ -0,0 +1,211 @@
+package saga
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// StatusRunning means step actions are being executed.
+	StatusRunning = "running"
+	// StatusCompensating means a step failed and completed steps are being undone.
+	StatusCompensating = "compensating"
+	// StatusCompleted means every step succeeded.
+	StatusCompleted = "completed"
+	// StatusAborted means a step failed and every compensation succeeded.
+	StatusAborted = "aborted"
+	// StatusFailed means a compensation kept failing; the saga needs an operator.
+	StatusFailed = "failed"
+)
+
+var (
+	ErrNotFound          = errors.New("saga not found")
+	ErrUnknownDefinition = errors.New("unknown saga definition")
+)
+
+// Endpoint is where a step's command is published.
+type Endpoint struct {
+	Exchange   string
+	RoutingKey string
+}
+
+// Step is one action of a saga and the compensation that undoes it. Steps
+// without a compensation are skipped when rolling back.
+type Step struct {
+	Name         string
+	Action       Endpoint
+	Compensation *Endpoint
+	// Timeout after which a missing reply counts as a failure. The timed out
+	// step is compensated as well, since its action may have been applied.
+	Timeout time.Duration
+}
+
+// Definition describes a saga as an ordered list of steps.
+type Definition struct {
+	Name  string
+	Steps []Step
+}
+
+// Instance is the persisted state of one saga.
+type Instance struct {
+	ID        string          `db:"id" json:"id"`
+	Name      string          `db:"name" json:"name"`
+	Status    string          `db:"status" json:"status"`
+	Step      int             `db:"step" json:"step"`
+	Attempts  int             `db:"attempts" json:"attempts"`
+	Data      json.RawMessage `db:"data" json:"data"`
+	Error     string          `db:"error" json:"error,omitempty"`
+	Deadline  *time.Time      `db:"deadline" json:"deadline,omitempty"`
+	CreatedAt time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+func (i *Instance) active() bool {
+	return i.Status == StatusRunning || i.Status == StatusCompensating
+}
+
+type outcome int
+
+const (
+	succeeded outcome = iota
+	failed
+	timedOut
+)
+
+// apply moves inst past the outcome of its current command and reports
+// whether another command has to be sent for inst.Step.
+func (d Definition) apply(inst *Instance, result outcome, reason string, data json.RawMessage, maxAttempts int) bool {
+	switch inst.Status {
+	case StatusRunning:
+		if result == succeeded {
+			if len(data) > 0 {
+				inst.Data = data
+			}
+			inst.Step++
+			if inst.Step == len(d.Steps) {
+				inst.Status = StatusCompleted
+				return false
+			}
+			inst.Attempts = 0
+			return true
+		}
+
+		inst.Status = StatusCompensating
+		inst.Error = fmt.Sprintf("step %s: %s", d.Steps[inst.Step].Name, reason)
+		if result == failed {
+			// A failed action is assumed to have had no effect.
+			inst.Step--
+		}
+		return d.nextCompensation(inst)
+
+	case StatusCompensating:
+		if result == succeeded {
+			inst.Step--
+			return d.nextCompensation(inst)
+		}
+		inst.Attempts++
+		if inst.Attempts >= maxAttempts {
+			inst.Status = StatusFailed
+			inst.Error = fmt.Sprintf("compensating step %s: %s", d.Steps[inst.Step].Name, reason)
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+func (d Definition) nextCompensation(inst *Instance) bool {
+	for inst.Step >= 0 && d.Steps[inst.Step].Compensation == nil {
+		inst.Step--
+	}
+	if inst.Step < 0 {
+		inst.Step = 0
+		inst.Status = StatusAborted
+		return false
+	}
+	inst.Attempts = 0
+	return true
+}
+
+// correlation identifies the command a reply belongs to.
+type correlation struct {
+	SagaID     string
+	Step       int
+	Compensate bool
+}
+
+func (c correlation) String() string {
+	kind := "action"
+	if c.Compensate {
+		kind = "compensation"
+	}
+	return fmt.Sprintf("%s/%d/%s", c.SagaID, c.Step, kind)
+}
+
+func parseCorrelation(s string) (correlation, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 3 {
+		return correlation{}, fmt.Errorf("invalid saga correlation id %q", s)
+	}
+	step, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return correlation{}, fmt.Errorf("invalid saga correlation id %q", s)
+	}
+	return correlation{SagaID: parts[0], Step: step, Compensate: parts[2] == "compensation"}, nil
+}
+
+const instanceColumns = `id, name, status, step, attempts, data, error, deadline, created_at, updated_at`
+
+// Get returns the state of one saga.
+func (o *Orchestrator) Get(ctx context.Context, id string) (*Instance, error) {
+	var inst Instance
+	err := o.db.GetContext(ctx, &inst, `SELECT `+instanceColumns+` FROM sagas WHERE id = $1`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &inst, nil
+}
+
+// Stuck lists sagas that need attention: failed sagas and active sagas that
+// have not moved for longer than olderThan.
+func (o *Orchestrator) Stuck(ctx context.Context, olderThan time.Duration) ([]Instance, error) {
+	var stuck []Instance
+	err := o.db.SelectContext(ctx, &stuck, `
+		SELECT `+instanceColumns+` FROM sagas
+		WHERE status = $1 OR (status IN ($2, $3) AND updated_at < $4)
+		ORDER BY updated_at`,
+		StatusFailed, StatusRunning, StatusCompensating, time.Now().Add(-olderThan))
+	return stuck, err
+}
+
+// Retry restarts the compensation of a failed saga.
+func (o *Orchestrator) Retry(ctx context.Context, id string) error {
+	inst, err := o.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if inst.Status != StatusFailed {
+		return fmt.Errorf("saga %s is %s, only failed sagas can be retried", id, inst.Status)
+	}
+	def, err := o.definition(inst.Name)
+	if err != nil {
+		return err
+	}
+
+	inst.Status = StatusCompensating
+	inst.Attempts = 0
+	o.setDeadline(def, inst)
+	if err := o.save(ctx, o.db, inst); err != nil {
+		return err
+	}
+	return o.send(ctx, def, inst)
+}
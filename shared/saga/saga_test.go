@@ -0,0 +1,73 @@
+package saga
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var orderSaga = Definition{
+	Name: "place-order",
+	Steps: []Step{
+		{Name: "reserve", Action: Endpoint{"commands", "stock.reserve"}, Compensation: &Endpoint{"commands", "stock.release"}},
+		{Name: "notify", Action: Endpoint{"commands", "order.notify"}},
+		{Name: "charge", Action: Endpoint{"commands", "payment.charge"}, Compensation: &Endpoint{"commands", "payment.refund"}},
+		{Name: "confirm", Action: Endpoint{"commands", "order.confirm"}},
+	},
+}
+
+func TestApplyCompletes(t *testing.T) {
+	inst := &Instance{Status: StatusRunning, Data: json.RawMessage(`{}`)}
+
+	for i := 0; i < 3; i++ {
+		assert.True(t, orderSaga.apply(inst, succeeded, "", nil, 3))
+	}
+	assert.False(t, orderSaga.apply(inst, succeeded, "", json.RawMessage(`{"done":true}`), 3))
+	assert.Equal(t, StatusCompleted, inst.Status)
+	assert.JSONEq(t, `{"done":true}`, string(inst.Data))
+}
+
+func TestApplyFailureCompensatesCompletedSteps(t *testing.T) {
+	inst := &Instance{Status: StatusRunning, Step: 3}
+
+	// confirm fails: charge is compensated first, notify has nothing to undo.
+	assert.True(t, orderSaga.apply(inst, failed, "rejected", nil, 3))
+	assert.Equal(t, StatusCompensating, inst.Status)
+	assert.Equal(t, 2, inst.Step)
+	assert.Equal(t, "step confirm: rejected", inst.Error)
+
+	assert.True(t, orderSaga.apply(inst, succeeded, "", nil, 3))
+	assert.Equal(t, 0, inst.Step)
+
+	assert.False(t, orderSaga.apply(inst, succeeded, "", nil, 3))
+	assert.Equal(t, StatusAborted, inst.Status)
+}
+
+func TestApplyTimeoutCompensatesTimedOutStep(t *testing.T) {
+	inst := &Instance{Status: StatusRunning, Step: 2}
+
+	assert.True(t, orderSaga.apply(inst, timedOut, "timed out", nil, 3))
+	assert.Equal(t, StatusCompensating, inst.Status)
+	assert.Equal(t, 2, inst.Step)
+}
+
+func TestApplyCompensationGivesUp(t *testing.T) {
+	inst := &Instance{Status: StatusCompensating, Step: 0}
+
+	assert.True(t, orderSaga.apply(inst, failed, "unavailable", nil, 2))
+	assert.False(t, orderSaga.apply(inst, failed, "unavailable", nil, 2))
+	assert.Equal(t, StatusFailed, inst.Status)
+	assert.Equal(t, "compensating step reserve: unavailable", inst.Error)
+}
+
+func TestCorrelationRoundTrip(t *testing.T) {
+	corr := correlation{SagaID: "abc", Step: 2, Compensate: true}
+
+	parsed, err := parseCorrelation(corr.String())
+	assert.NoError(t, err)
+	assert.Equal(t, corr, parsed)
+
+	_, err = parseCorrelation("garbage")
+	assert.Error(t, err)
+}
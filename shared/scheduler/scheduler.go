@@ -0,0 +1,398 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MuxSphere/microkit/shared/database"
+	"github.com/MuxSphere/microkit/shared/rabbitmq"
+	"github.com/google/uuid"
+	"github.com/streadway/amqp"
+	"go.uber.org/zap"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusSent      = "sent"
+	StatusCancelled = "cancelled"
+)
+
+// ErrNotPending is returned when cancelling a message that was already sent,
+// cancelled or never scheduled.
+var ErrNotPending = errors.New("scheduled message is not pending")
+
+type record struct {
+	ID            string       `db:"id"`
+	Exchange      string       `db:"exchange"`
+	RoutingKey    string       `db:"routing_key"`
+	MessageID     string       `db:"message_id"`
+	MessageType   string       `db:"message_type"`
+	ContentType   string       `db:"content_type"`
+	Headers       headers      `db:"headers"`
+	Timestamp     sql.NullTime `db:"timestamp"`
+	AppID         string       `db:"app_id"`
+	CorrelationID string       `db:"correlation_id"`
+	ReplyTo       string       `db:"reply_to"`
+	DeliveryMode  uint8        `db:"delivery_mode"`
+	Body          []byte       `db:"body"`
+	DeliverAt     time.Time    `db:"deliver_at"`
+}
+
+func newRecord(exchange, routingKey string, msg amqp.Publishing, at time.Time) record {
+	return record{
+		ID:            uuid.NewString(),
+		Exchange:      exchange,
+		RoutingKey:    routingKey,
+		MessageID:     msg.MessageId,
+		MessageType:   msg.Type,
+		ContentType:   msg.ContentType,
+		Headers:       headers(msg.Headers),
+		Timestamp:     sql.NullTime{Time: msg.Timestamp, Valid: !msg.Timestamp.IsZero()},
+		AppID:         msg.AppId,
+		CorrelationID: msg.CorrelationId,
+		ReplyTo:       msg.ReplyTo,
+		DeliveryMode:  msg.DeliveryMode,
+		Body:          msg.Body,
+		DeliverAt:     at,
+	}
+}
+
+// publishing restores the message that was scheduled. Messages scheduled
+// without a delivery mode are published as persistent.
+func (r record) publishing() amqp.Publishing {
+	msg := amqp.Publishing{
+		MessageId:     r.MessageID,
+		Type:          r.MessageType,
+		ContentType:   r.ContentType,
+		Headers:       amqp.Table(r.Headers),
+		AppId:         r.AppID,
+		CorrelationId: r.CorrelationID,
+		ReplyTo:       r.ReplyTo,
+		DeliveryMode:  r.DeliveryMode,
+		Body:          r.Body,
+	}
+	if r.Timestamp.Valid {
+		msg.Timestamp = r.Timestamp.Time
+	}
+	if msg.DeliveryMode == 0 {
+		msg.DeliveryMode = amqp.Persistent
+	}
+	return msg
+}
+
+// headers stores message headers as JSON. Each value is tagged with its type,
+// so it is restored as the same Go type it was scheduled with.
+type headers amqp.Table
+
+// typedValue is a header value and the name of its type.
+type typedValue struct {
+	Type  string          `json:"t"`
+	Value json.RawMessage `json:"v,omitempty"`
+}
+
+func (h headers) Value() (driver.Value, error) {
+	table, err := encodeTable(amqp.Table(h))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(table)
+}
+
+func (h *headers) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	case nil:
+		*h = nil
+		return nil
+	default:
+		return fmt.Errorf("scheduler: cannot scan %T into headers", src)
+	}
+
+	var table map[string]typedValue
+	if err := json.Unmarshal(data, &table); err != nil {
+		return err
+	}
+	if len(table) == 0 {
+		*h = nil
+		return nil
+	}
+	restored, err := decodeTable(table)
+	if err != nil {
+		return err
+	}
+	*h = headers(restored)
+	return nil
+}
+
+func encodeTable(table amqp.Table) (map[string]typedValue, error) {
+	out := make(map[string]typedValue, len(table))
+	for k, v := range table {
+		tv, err := encodeValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("header %q: %w", k, err)
+		}
+		out[k] = tv
+	}
+	return out, nil
+}
+
+// encodeValue tags v with its type. It accepts the types an amqp.Table may
+// hold.
+func encodeValue(v interface{}) (typedValue, error) {
+	var typ string
+	value := v
+	switch v := v.(type) {
+	case nil:
+		return typedValue{Type: "nil"}, nil
+	case bool:
+		typ = "bool"
+	case byte:
+		typ = "byte"
+	case int:
+		typ = "int"
+	case int16:
+		typ = "int16"
+	case int32:
+		typ = "int32"
+	case int64:
+		typ = "int64"
+	case float32:
+		typ = "float32"
+	case float64:
+		typ = "float64"
+	case string:
+		typ = "string"
+	case []byte:
+		typ = "bytes"
+	case amqp.Decimal:
+		typ = "decimal"
+	case time.Time:
+		typ = "timestamp"
+	case amqp.Table:
+		table, err := encodeTable(v)
+		if err != nil {
+			return typedValue{}, err
+		}
+		typ, value = "table", table
+	case []interface{}:
+		array := make([]typedValue, len(v))
+		for i, item := range v {
+			tv, err := encodeValue(item)
+			if err != nil {
+				return typedValue{}, err
+			}
+			array[i] = tv
+		}
+		typ, value = "array", array
+	default:
+		return typedValue{}, fmt.Errorf("scheduler: unsupported header type %T", v)
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return typedValue{}, err
+	}
+	return typedValue{Type: typ, Value: raw}, nil
+}
+
+func decodeTable(table map[string]typedValue) (amqp.Table, error) {
+	out := make(amqp.Table, len(table))
+	for k, tv := range table {
+		v, err := decodeValue(tv)
+		if err != nil {
+			return nil, fmt.Errorf("header %q: %w", k, err)
+		}
+		out[k] = v
+	}
+	return out, nil
+}
+
+// decodeValue restores a value tagged by encodeValue.
+func decodeValue(tv typedValue) (interface{}, error) {
+	switch tv.Type {
+	case "nil":
+		return nil, nil
+	case "bool":
+		return decodeAs[bool](tv.Value)
+	case "byte":
+		return decodeAs[byte](tv.Value)
+	case "int":
+		return decodeAs[int](tv.Value)
+	case "int16":
+		return decodeAs[int16](tv.Value)
+	case "int32":
+		return decodeAs[int32](tv.Value)
+	case "int64":
+		return decodeAs[int64](tv.Value)
+	case "float32":
+		return decodeAs[float32](tv.Value)
+	case "float64":
+		return decodeAs[float64](tv.Value)
+	case "string":
+		return decodeAs[string](tv.Value)
+	case "bytes":
+		return decodeAs[[]byte](tv.Value)
+	case "decimal":
+		return decodeAs[amqp.Decimal](tv.Value)
+	case "timestamp":
+		return decodeAs[time.Time](tv.Value)
+	case "table":
+		var table map[string]typedValue
+		if err := json.Unmarshal(tv.Value, &table); err != nil {
+			return nil, err
+		}
+		return decodeTable(table)
+	case "array":
+		var array []typedValue
+		if err := json.Unmarshal(tv.Value, &array); err != nil {
+			return nil, err
+		}
+		out := make([]interface{}, len(array))
+		for i, item := range array {
+			v, err := decodeValue(item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = v
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("scheduler: unknown header type %q", tv.Type)
+	}
+}
+
+func decodeAs[T any](raw json.RawMessage) (interface{}, error) {
+	var v T
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Scheduler stores messages in Postgres and publishes them when they are due.
+// Unlike rabbitmq.PublishAt, scheduled messages can be cancelled until then.
+type Scheduler struct {
+	db     database.DB
+	mq     rabbitmq.Broker
+	logger *zap.Logger
+
+	// Interval between polls for due messages.
+	Interval time.Duration
+	// BatchSize is the maximum number of messages published per poll.
+	BatchSize int
+}
+
+func New(db database.DB, mq rabbitmq.Broker, logger *zap.Logger) *Scheduler {
+	return &Scheduler{
+		db:        db,
+		mq:        mq,
+		logger:    logger,
+		Interval:  time.Second,
+		BatchSize: 100,
+	}
+}
+
+// Schedule stores msg for delivery to exchange at the given time and returns
+// an ID that can be passed to Cancel. Header values must have a type that
+// amqp.Table accepts.
+func (s *Scheduler) Schedule(ctx context.Context, exchange, routingKey string, msg amqp.Publishing, at time.Time) (string, error) {
+	if err := msg.Headers.Validate(); err != nil {
+		return "", err
+	}
+	rec := newRecord(exchange, routingKey, msg, at)
+	_, err := s.db.NamedExecContext(ctx, `
+		INSERT INTO scheduled_messages (id, exchange, routing_key, message_id, message_type, content_type, headers, timestamp, app_id, correlation_id, reply_to, delivery_mode, body, deliver_at)
+		VALUES (:id, :exchange, :routing_key, :message_id, :message_type, :content_type, :headers, :timestamp, :app_id, :correlation_id, :reply_to, :delivery_mode, :body, :deliver_at)`, rec)
+	if err != nil {
+		return "", err
+	}
+	return rec.ID, nil
+}
+
+// Cancel prevents a pending message from being published.
+func (s *Scheduler) Cancel(ctx context.Context, id string) error {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE scheduled_messages SET status = $2, updated_at = now()
+		WHERE id = $1 AND status = $3`, id, StatusCancelled, StatusPending)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotPending
+	}
+	return nil
+}
+
+// Run publishes due messages until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	s.logger.Info("Starting message scheduler")
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.PublishDue(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Error("Failed to publish scheduled messages", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Message scheduler stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PublishDue publishes one batch of due messages and returns how many were
+// sent. Rows are locked while publishing, so several replicas can run it.
+func (s *Scheduler) PublishDue(ctx context.Context) (int, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var due []record
+	err = tx.SelectContext(ctx, &due, `
+		SELECT id, exchange, routing_key, message_id, message_type, content_type, headers, timestamp, app_id, correlation_id, reply_to, delivery_mode, body, deliver_at
+		FROM scheduled_messages
+		WHERE status = $1 AND deliver_at <= now()
+		ORDER BY deliver_at
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED`, StatusPending, s.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, rec := range due {
+		if err := s.mq.PublishConfirmed(ctx, rec.Exchange, rec.RoutingKey, rec.publishing()); err != nil {
+			s.logger.Warn("Failed to publish scheduled message", zap.String("id", rec.ID), zap.Error(err))
+			continue
+		}
+		_, err := tx.ExecContext(ctx, `
+			UPDATE scheduled_messages SET status = $2, updated_at = now() WHERE id = $1`, rec.ID, StatusSent)
+		if err != nil {
+			return 0, err
+		}
+		sent++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return sent, nil
+}
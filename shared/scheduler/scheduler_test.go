@@ -0,0 +1,146 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/MuxSphere/microkit/shared/database/databasetest"
+	"github.com/MuxSphere/microkit/shared/rabbitmq"
+	"github.com/MuxSphere/microkit/shared/rabbitmq/mocks"
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var scheduledColumns = []string{"id", "exchange", "routing_key", "message_id", "message_type", "content_type", "headers", "timestamp", "app_id", "correlation_id", "reply_to", "delivery_mode", "body", "deliver_at"}
+
+// captured is a sqlmock argument that accepts any value and remembers it.
+type captured struct {
+	value driver.Value
+}
+
+func (c *captured) Match(v driver.Value) bool {
+	c.value = v
+	return true
+}
+
+func TestScheduleAndPublishDue(t *testing.T) {
+	db, sqlMock, err := databasetest.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mq := mocks.NewBroker(t)
+	s := New(db, mq, zap.NewNop())
+
+	sent := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	at := sent.Add(time.Hour)
+	env := rabbitmq.Envelope{ID: "m1", Type: "reservation.expired", Source: "service-a", Time: sent, SchemaVersion: 2, ContentType: rabbitmq.ContentTypeJSON, Data: []byte(`{}`)}
+	msg := env.Publishing()
+	msg.CorrelationId = "c1"
+	msg.ReplyTo = "amq.rabbitmq.reply-to"
+	msg.Headers["attempts"] = 3
+	msg.Headers["trace"] = amqp.Table{"sampled": true, "id": int64(1) << 60, "parent": []byte{0, 1, 2}}
+	msg.Headers["first-seen"] = sent
+	msg.Headers["ratio"] = float32(0.5)
+	msg.Headers["price"] = amqp.Decimal{Scale: 2, Value: 1999}
+	msg.Headers["hops"] = []interface{}{byte(1), int16(2), "three", nil}
+
+	var id, hdrs captured
+	sqlMock.ExpectExec(`INSERT INTO scheduled_messages`).
+		WithArgs(&id, "events", "reservation.expired", "m1", "reservation.expired", rabbitmq.ContentTypeJSON, &hdrs, sent, "service-a", "c1", "amq.rabbitmq.reply-to", int64(amqp.Persistent), []byte(`{}`), at).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	scheduled, err := s.Schedule(context.Background(), "events", "reservation.expired", msg, at)
+	require.NoError(t, err)
+	assert.Equal(t, scheduled, id.value)
+
+	// The stored row is read back into the same message
+	var published amqp.Publishing
+	mq.EXPECT().PublishConfirmed(mock.Anything, "events", "reservation.expired", mock.Anything).
+		RunAndReturn(func(ctx context.Context, exchange, key string, msg amqp.Publishing) error {
+			published = msg
+			return nil
+		})
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery(`SELECT .* FROM scheduled_messages WHERE status = \$1 AND deliver_at <= now\(\)`).
+		WithArgs(StatusPending, 100).
+		WillReturnRows(sqlmock.NewRows(scheduledColumns).
+			AddRow(scheduled, "events", "reservation.expired", "m1", "reservation.expired", rabbitmq.ContentTypeJSON, hdrs.value, sent, "service-a", "c1", "amq.rabbitmq.reply-to", 2, []byte(`{}`), at))
+	sqlMock.ExpectExec(`UPDATE scheduled_messages SET status = \$2`).WithArgs(scheduled, StatusSent).WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+
+	n, err := s.PublishDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+
+	// Header values keep their types
+	assert.Equal(t, msg.Headers, published.Headers)
+	assert.Equal(t, env, rabbitmq.EnvelopeFromDelivery(amqp.Delivery{
+		MessageId:   published.MessageId,
+		Type:        published.Type,
+		AppId:       published.AppId,
+		Timestamp:   published.Timestamp,
+		ContentType: published.ContentType,
+		Headers:     published.Headers,
+		Body:        published.Body,
+	}))
+	assert.Equal(t, "c1", published.CorrelationId)
+	assert.Equal(t, "amq.rabbitmq.reply-to", published.ReplyTo)
+	assert.Equal(t, amqp.Persistent, published.DeliveryMode)
+}
+
+func TestScheduleRejectsUnsupportedHeaders(t *testing.T) {
+	db, sqlMock, err := databasetest.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := New(db, mocks.NewBroker(t), zap.NewNop())
+	msg := amqp.Publishing{Headers: amqp.Table{"trace": amqp.Table{"span": uint64(1)}}}
+	_, err = s.Schedule(context.Background(), "events", "reminder.due", msg, time.Now())
+	assert.Error(t, err)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestPublishDueKeepsFailedMessagesPending(t *testing.T) {
+	db, sqlMock, err := databasetest.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mq := mocks.NewBroker(t)
+	mq.EXPECT().PublishConfirmed(mock.Anything, "events", "reminder.due", mock.Anything).Return(errors.New("nacked"))
+	s := New(db, mq, zap.NewNop())
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery(`SELECT .* FROM scheduled_messages`).
+		WillReturnRows(sqlmock.NewRows(scheduledColumns).
+			AddRow("s1", "events", "reminder.due", "m1", "reminder.due", "text/plain", []byte(`{}`), nil, "", "", "", 0, []byte("hi"), time.Now()))
+	sqlMock.ExpectCommit()
+
+	n, err := s.PublishDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestCancel(t *testing.T) {
+	db, sqlMock, err := databasetest.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := New(db, mocks.NewBroker(t), zap.NewNop())
+	cancel := `UPDATE scheduled_messages SET status = \$2, updated_at = now\(\) WHERE id = \$1 AND status = \$3`
+
+	sqlMock.ExpectExec(cancel).WithArgs("s1", StatusCancelled, StatusPending).WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, s.Cancel(context.Background(), "s1"))
+
+	// Sent, cancelled and unknown messages match no pending row
+	sqlMock.ExpectExec(cancel).WithArgs("s1", StatusCancelled, StatusPending).WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, s.Cancel(context.Background(), "s1"), ErrNotPending)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
@@ -0,0 +1,140 @@
+// Package secrets resolves configuration values that reference a secret
+// instead of holding it, such as file:///run/secrets/jwt_secret for Docker and
+// Kubernetes secrets or secret://db/password for a secret store.
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ErrNotFound is returned when a store has no secret with the given name.
+var ErrNotFound = errors.New("secret not found")
+
+// Provider looks up the secret a reference points to. Implementations for
+// external stores are registered on a Resolver under their own scheme.
+type Provider interface {
+	Secret(ctx context.Context, ref *url.URL) (string, error)
+}
+
+// File reads file:// references. Trailing newlines are removed, as secret
+// files often end with one.
+type File struct{}
+
+func (File) Secret(ctx context.Context, ref *url.URL) (string, error) {
+	data, err := os.ReadFile(ref.Host + ref.Path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// FileStore is a local stand-in for an external secret store. It serves
+// secret://<name> references from a JSON object of names to values. The file
+// is read on every lookup, so edits take effect like a rotation would.
+type FileStore struct {
+	path string
+}
+
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+func (s *FileStore) Secret(ctx context.Context, ref *url.URL) (string, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return "", err
+	}
+	var values map[string]string
+	if err := json.Unmarshal(data, &values); err != nil {
+		return "", fmt.Errorf("%s: %w", s.path, err)
+	}
+	name := strings.TrimPrefix(ref.Host+ref.Path, "/")
+	v, ok := values[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return v, nil
+}
+
+// Resolver dispatches references to the provider registered for their scheme.
+type Resolver struct {
+	mu        sync.RWMutex
+	providers map[string]Provider
+	refs      map[string]string
+}
+
+// NewResolver returns a Resolver that reads file:// references.
+func NewResolver() *Resolver {
+	return &Resolver{
+		providers: map[string]Provider{"file": File{}},
+		refs:      make(map[string]string),
+	}
+}
+
+// Register makes p resolve references with the given scheme.
+func (r *Resolver) Register(scheme string, p Provider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[scheme] = p
+}
+
+// IsRef reports whether s references a secret of a registered scheme.
+func (r *Resolver) IsRef(s string) bool {
+	_, _, ok := r.provider(s)
+	return ok
+}
+
+func (r *Resolver) provider(s string) (Provider, *url.URL, bool) {
+	scheme, _, found := strings.Cut(s, "://")
+	if !found {
+		return nil, nil, false
+	}
+	r.mu.RLock()
+	p, ok := r.providers[scheme]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, nil, false
+	}
+	ref, err := url.Parse(s)
+	if err != nil {
+		return nil, nil, false
+	}
+	return p, ref, true
+}
+
+// Resolve returns the secret s references, or s itself when it is not a
+// reference. The reference is remembered so that Refresh can look it up again.
+func (r *Resolver) Resolve(ctx context.Context, s string) (string, error) {
+	p, ref, ok := r.provider(s)
+	if !ok {
+		return s, nil
+	}
+	v, err := p.Secret(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	r.mu.Lock()
+	r.refs[v] = s
+	r.mu.Unlock()
+	return v, nil
+}
+
+// Refresh returns the current value of a secret previously returned by
+// Resolve, which differs from value once the secret has been rotated. Values
+// that did not come from a reference are returned unchanged.
+func (r *Resolver) Refresh(ctx context.Context, value string) (string, error) {
+	r.mu.RLock()
+	ref, ok := r.refs[value]
+	r.mu.RUnlock()
+	if !ok {
+		return value, nil
+	}
+	return r.Resolve(ctx, ref)
+}
@@ -0,0 +1,62 @@
+package secrets
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwt_secret")
+	require.NoError(t, os.WriteFile(path, []byte("s3cret\n"), 0o600))
+
+	r := NewResolver()
+	assert.True(t, r.IsRef("file://"+path))
+	v, err := r.Resolve(context.Background(), "file://"+path)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", v)
+
+	_, err = r.Resolve(context.Background(), "file://"+path+".missing")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestResolveLeavesPlainValues(t *testing.T) {
+	r := NewResolver()
+	for _, s := range []string{"plain", "postgres://user:pass@db/app", "secret://db/password"} {
+		assert.False(t, r.IsRef(s), s)
+		v, err := r.Resolve(context.Background(), s)
+		require.NoError(t, err)
+		assert.Equal(t, s, v)
+	}
+}
+
+func TestFileStoreAndRefresh(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"db/url": "postgres://app:one@db/app"}`), 0o600))
+
+	r := NewResolver()
+	r.Register("secret", NewFileStore(path))
+	v, err := r.Resolve(context.Background(), "secret://db/url")
+	require.NoError(t, err)
+	assert.Equal(t, "postgres://app:one@db/app", v)
+
+	_, err = r.Resolve(context.Background(), "secret://db/missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// Rotating the secret is picked up from the old value
+	require.NoError(t, os.WriteFile(path, []byte(`{"db/url": "postgres://app:two@db/app"}`), 0o600))
+	v, err = r.Refresh(context.Background(), "postgres://app:one@db/app")
+	require.NoError(t, err)
+	assert.Equal(t, "postgres://app:two@db/app", v)
+	v, err = r.Refresh(context.Background(), "postgres://app:two@db/app")
+	require.NoError(t, err)
+	assert.Equal(t, "postgres://app:two@db/app", v)
+
+	v, err = r.Refresh(context.Background(), "literal")
+	require.NoError(t, err)
+	assert.Equal(t, "literal", v)
+}
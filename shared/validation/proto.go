@@ -0,0 +1,154 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+	"unicode/utf8"
+
+	validatepb "github.com/MuxSphere/microkit/proto/validate"
+	apperrors "github.com/MuxSphere/microkit/shared/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// patterns caches compiled string patterns by expression.
+var patterns sync.Map
+
+// Message validates msg, including nested messages, against the
+// (validate.field) options of its fields. Field paths use the proto names,
+// e.g. "items[0].name".
+func Message(msg proto.Message) error {
+	var fields []apperrors.FieldViolation
+	checkMessage(msg.ProtoReflect(), "", &fields)
+	if len(fields) == 0 {
+		return nil
+	}
+	return invalid(fields)
+}
+
+func checkMessage(m protoreflect.Message, prefix string, out *[]apperrors.FieldViolation) {
+	fds := m.Descriptor().Fields()
+	for i := 0; i < fds.Len(); i++ {
+		fd := fds.Get(i)
+		path := prefix + string(fd.Name())
+
+		if rules, ok := proto.GetExtension(fd.Options(), validatepb.E_Field).(*validatepb.FieldRules); ok && rules != nil {
+			if problem := checkField(m, fd, rules); problem != "" {
+				*out = append(*out, apperrors.FieldViolation{Field: path, Description: problem})
+			}
+		}
+
+		if fd.Kind() != protoreflect.MessageKind || fd.IsMap() || !m.Has(fd) {
+			continue
+		}
+		if fd.IsList() {
+			list := m.Get(fd).List()
+			for j := 0; j < list.Len(); j++ {
+				checkMessage(list.Get(j).Message(), fmt.Sprintf("%s[%d].", path, j), out)
+			}
+			continue
+		}
+		checkMessage(m.Get(fd).Message(), path+".", out)
+	}
+}
+
+// checkField returns why the field breaks rules, or "" if it does not.
+func checkField(m protoreflect.Message, fd protoreflect.FieldDescriptor, rules *validatepb.FieldRules) string {
+	if !m.Has(fd) {
+		if rules.Required {
+			return "is required"
+		}
+		// Unset scalars are checked as their zero value below
+		if fd.IsList() || fd.IsMap() || fd.Kind() == protoreflect.MessageKind {
+			return ""
+		}
+	}
+	if fd.IsList() || fd.IsMap() {
+		return ""
+	}
+	value := m.Get(fd)
+
+	if s := rules.GetString_(); s != nil && fd.Kind() == protoreflect.StringKind {
+		str := value.String()
+		n := uint64(utf8.RuneCountInString(str))
+		switch {
+		case s.MinLen != nil && n < s.GetMinLen():
+			if s.GetMinLen() == 1 {
+				return "must not be empty"
+			}
+			return fmt.Sprintf("must be at least %d characters", s.GetMinLen())
+		case s.MaxLen != nil && n > s.GetMaxLen():
+			return fmt.Sprintf("must be at most %d characters", s.GetMaxLen())
+		case s.Pattern != nil && !pattern(s.GetPattern()).MatchString(str):
+			return fmt.Sprintf("must match %s", s.GetPattern())
+		}
+	}
+
+	if r := rules.GetInt(); r != nil {
+		var n int64
+		switch fd.Kind() {
+		case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+			protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+			n = value.Int()
+		case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+			n = int64(value.Uint())
+		default:
+			return ""
+		}
+		switch {
+		case r.Gte != nil && n < r.GetGte():
+			return fmt.Sprintf("must be at least %d", r.GetGte())
+		case r.Lte != nil && n > r.GetLte():
+			return fmt.Sprintf("must be at most %d", r.GetLte())
+		}
+	}
+	return ""
+}
+
+func pattern(expr string) *regexp.Regexp {
+	if re, ok := patterns.Load(expr); ok {
+		return re.(*regexp.Regexp)
+	}
+	re := regexp.MustCompile(expr)
+	patterns.Store(expr, re)
+	return re
+}
+
+// UnaryServerInterceptor rejects requests that fail Message before they
+// reach the handler.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if msg, ok := req.(proto.Message); ok {
+			if err := Message(msg); err != nil {
+				return nil, err
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor validates every message the client sends. An
+// invalid message is returned by Recv as an error, so a handler may either
+// end the stream with it or skip the message and keep receiving.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &validatingStream{ServerStream: ss})
+	}
+}
+
+type validatingStream struct {
+	grpc.ServerStream
+}
+
+func (s *validatingStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if msg, ok := m.(proto.Message); ok {
+		return Message(msg)
+	}
+	return nil
+}
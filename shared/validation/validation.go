@@ -0,0 +1,111 @@
+// Package validation checks incoming requests and reports failures as
+// apperrors invalid argument errors with one violation per field. Gin
+// requests are validated against their binding tags, protobuf messages
+// against the (validate.field) options in their .proto files.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	apperrors "github.com/MuxSphere/microkit/shared/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+var useJSONNames sync.Once
+
+// setup makes Gin's validator report fields by their JSON name.
+func setup() {
+	useJSONNames.Do(func() {
+		if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+			v.RegisterTagNameFunc(func(f reflect.StructField) string {
+				name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+				if name == "-" {
+					return ""
+				}
+				return name
+			})
+		}
+	})
+}
+
+// Struct validates v against its binding tags, the rules Gin applies when
+// binding a request.
+func Struct(v interface{}) error {
+	setup()
+	if err := binding.Validator.ValidateStruct(v); err != nil {
+		return FromBinding(err)
+	}
+	return nil
+}
+
+// FromBinding converts an error returned by Gin binding. Failed rules become
+// field violations; anything else means the body could not be decoded.
+func FromBinding(err error) error {
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return apperrors.Wrap(err, apperrors.CodeInvalidArgument, "malformed request body")
+	}
+	fields := make([]apperrors.FieldViolation, len(errs))
+	for i, fe := range errs {
+		fields[i] = apperrors.FieldViolation{Field: fe.Field(), Description: Describe(fe)}
+	}
+	return invalid(fields)
+}
+
+// Describe explains a failed rule, e.g. "must be at most 100 characters".
+func Describe(fe validator.FieldError) string {
+	unit := ""
+	if fe.Kind() == reflect.String {
+		unit = " characters"
+	}
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min", "gte":
+		return fmt.Sprintf("must be at least %s%s", fe.Param(), unit)
+	case "max", "lte":
+		return fmt.Sprintf("must be at most %s%s", fe.Param(), unit)
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	default:
+		return fmt.Sprintf("failed the %s rule", fe.Tag())
+	}
+}
+
+// invalid joins the violations into one message, e.g. "name is required".
+func invalid(fields []apperrors.FieldViolation) error {
+	messages := make([]string, len(fields))
+	for i, f := range fields {
+		messages[i] = f.Field + " " + f.Description
+	}
+	return apperrors.InvalidArgument(strings.Join(messages, "; "), fields...)
+}
+
+const bodyKey = "validation.body"
+
+// JSON returns middleware that binds the request body into a T and validates
+// it, answering 400 with problem details on failure. Handlers read the value
+// with Body.
+func JSON[T any]() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		setup()
+		var body T
+		if err := c.ShouldBindJSON(&body); err != nil {
+			apperrors.WriteProblem(c, FromBinding(err))
+			return
+		}
+		c.Set(bodyKey, &body)
+		c.Next()
+	}
+}
+
+// Body returns the request body bound by JSON[T].
+func Body[T any](c *gin.Context) *T {
+	return c.MustGet(bodyKey).(*T)
+}
@@ -0,0 +1,91 @@
+package validation
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MuxSphere/microkit/proto"
+	apperrors "github.com/MuxSphere/microkit/shared/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+type widget struct {
+	Name  string `json:"name" binding:"required,max=5"`
+	Count int    `json:"count" binding:"gte=1"`
+}
+
+func TestStruct(t *testing.T) {
+	assert.NoError(t, Struct(widget{Name: "gear", Count: 1}))
+
+	err := Struct(widget{Name: "sprocket"})
+	require.True(t, apperrors.HasCode(err, apperrors.CodeInvalidArgument))
+	assert.Equal(t, []apperrors.FieldViolation{
+		{Field: "name", Description: "must be at most 5 characters"},
+		{Field: "count", Description: "must be at least 1"},
+	}, apperrors.From(err).Fields)
+}
+
+func TestJSONMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/widgets", JSON[widget](), func(c *gin.Context) {
+		c.JSON(http.StatusOK, Body[widget](c))
+	})
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("POST", "/widgets", strings.NewReader(body)))
+		return w
+	}
+
+	w := post(`{"name":"gear","count":2}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"name":"gear","count":2}`, w.Body.String())
+
+	w = post(`{"count":2}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, apperrors.ContentTypeProblem, w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `"errors":[{"field":"name","description":"is required"}]`)
+
+	w = post(`{"name":`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "malformed request body")
+}
+
+func TestMessage(t *testing.T) {
+	assert.NoError(t, Message(&proto.CreateItemRequest{Name: "gear", Quantity: 1}))
+
+	err := Message(&proto.CreateItemRequest{Name: "", Description: strings.Repeat("x", 1001), Quantity: -1})
+	assert.Equal(t, []apperrors.FieldViolation{
+		{Field: "name", Description: "must not be empty"},
+		{Field: "description", Description: "must be at most 1000 characters"},
+		{Field: "quantity", Description: "must be at least 0"},
+	}, apperrors.From(err).Fields)
+
+	err = Message(&proto.ListItemsRequest{Limit: 500, Sort: "name; drop"})
+	assert.Len(t, apperrors.From(err).Fields, 2)
+	err = Message(&proto.GetItemRequest{})
+	assert.Equal(t, "id is required", apperrors.From(err).Message)
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	called := false
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		called = true
+		return nil, nil
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/service.GreeterService/SayHello"}
+
+	_, err := UnaryServerInterceptor()(context.Background(), &proto.HelloRequest{}, info, handler)
+	assert.True(t, apperrors.HasCode(err, apperrors.CodeInvalidArgument))
+	assert.False(t, called)
+
+	_, err = UnaryServerInterceptor()(context.Background(), &proto.HelloRequest{Name: "Ada"}, info, handler)
+	assert.NoError(t, err)
+	assert.True(t, called)
+}